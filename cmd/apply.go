@@ -0,0 +1,68 @@
+/*
+Copyright © 2024 NAME HERE <EMAIL ADDRESS>
+*/
+package cmd
+
+import (
+	"os"
+
+	"github.com/mona-actions/gh-migrate-teams/pkg/sync"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+// applyCmd represents the apply command
+var applyCmd = &cobra.Command{
+	Use:   "apply",
+	Short: "Applies a plan created by the plan command to the target organization",
+	Long: `Applies a plan created by the plan command to the target organization.
+
+	The plan is refused if it was modified after it was created or if any of its teams changed in the target organization since planning.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		// Get parameters
+		planFile := cmd.Flag("plan").Value.String()
+		targetToken := cmd.Flag("target-token").Value.String()
+		targetHostname := cmd.Flag("target-hostname").Value.String()
+		writeInterval := cmd.Flag("write-interval").Value.String()
+		journalFile := cmd.Flag("journal-file").Value.String()
+		reportFile := cmd.Flag("report-file").Value.String()
+
+		// Set ENV variables
+		os.Setenv("GHMT_PLAN_FILE", planFile)
+		os.Setenv("GHMT_TARGET_TOKEN", targetToken)
+		os.Setenv("GHMT_TARGET_HOSTNAME", targetHostname)
+		os.Setenv("GHMT_WRITE_INTERVAL", writeInterval)
+		os.Setenv("GHMT_JOURNAL_FILE", journalFile)
+		os.Setenv("GHMT_REPORT_FILE", reportFile)
+
+		// Bind ENV variables in Viper
+		viper.BindEnv("PLAN_FILE")
+		viper.BindEnv("TARGET_TOKEN")
+		viper.BindEnv("TARGET_HOSTNAME")
+		viper.BindEnv("WRITE_INTERVAL")
+		viper.BindEnv("JOURNAL_FILE")
+		viper.BindEnv("REPORT_FILE")
+
+		// Call applyPlan
+		sync.ApplyPlan()
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(applyCmd)
+
+	// Flags
+	applyCmd.Flags().StringP("plan", "p", "", "Plan file created by the plan command")
+	applyCmd.MarkFlagRequired("plan")
+
+	applyCmd.Flags().StringP("target-token", "b", "", "Target Organization GitHub token. Scopes: admin:org")
+	applyCmd.MarkFlagRequired("target-token")
+
+	applyCmd.Flags().String("target-hostname", "", "GitHub Enterprise Server or GHE.com target hostname (optional) Ex. https://github.example.com or acme.ghe.com")
+
+	applyCmd.Flags().Duration("write-interval", 0, "Minimum time between write requests to avoid secondary rate limits. Ex. 1s")
+
+	applyCmd.Flags().StringP("journal-file", "j", "gh-migrate-teams-journal.jsonl", "Append-only file recording every write to the target organization, used by rollback")
+
+	applyCmd.Flags().String("report-file", "gh-migrate-teams-report.json", "File to write the outcome of every operation to as JSON. A CSV copy is written next to it")
+}
@@ -23,40 +23,139 @@ var byReposCmd = &cobra.Command{
 
 		targetOrganization := cmd.Flag("target-organization").Value.String()
 		sourceToken := cmd.Flag("source-token").Value.String()
+		sourceAppId := cmd.Flag("source-app-id").Value.String()
+		sourcePrivateKey := cmd.Flag("source-private-key").Value.String()
+		sourceInstallationId := cmd.Flag("source-installation-id").Value.String()
 		targetToken := cmd.Flag("target-token").Value.String()
 		mappingFile := cmd.Flag("mapping-file").Value.String()
+		mappingFormat := cmd.Flag("mapping-format").Value.String()
+		teamMappingFile := cmd.Flag("team-mapping-file").Value.String()
+		repoMappingFile := cmd.Flag("repo-mapping-file").Value.String()
 		ghHostname := cmd.Flag("source-hostname").Value.String()
+		targetHostname := cmd.Flag("target-hostname").Value.String()
 		repoFile := cmd.Flag("from-file").Value.String()
 		skipTeams := cmd.Flag("skip-teams").Value.String()
+		prune := cmd.Flag("prune").Value.String()
+		pruneLimit := cmd.Flag("prune-limit").Value.String()
+		pruneAllowlist := cmd.Flag("prune-allowlist").Value.String()
+		reconcile := cmd.Flag("reconcile").Value.String()
 		includeAllRepos, _ := cmd.Flags().GetBool("include-all-repos")
 		tAppId := cmd.Flag("target-app-id").Value.String()
 		tInstallationId := cmd.Flag("target-installation-id").Value.String()
+		concurrency := cmd.Flag("concurrency").Value.String()
+		writeInterval := cmd.Flag("write-interval").Value.String()
+		stateFile := cmd.Flag("state-file").Value.String()
+		resume := cmd.Flag("resume").Value.String()
+		journalFile := cmd.Flag("journal-file").Value.String()
+		reportFile := cmd.Flag("report-file").Value.String()
+		createCustomRoles := cmd.Flag("create-custom-roles").Value.String()
+		includeCollaborators := cmd.Flag("include-collaborators").Value.String()
+		renameRules := cmd.Flag("rename-rules").Value.String()
+		externalGroupMappingFile := cmd.Flag("external-group-mapping-file").Value.String()
+		idpGroupMappingFile := cmd.Flag("idp-group-mapping-file").Value.String()
+		identityMatch := cmd.Flag("identity-match").Value.String()
+		identityReviewFile := cmd.Flag("identity-review-file").Value.String()
+		targetEnterprise := cmd.Flag("target-enterprise").Value.String()
+		emuShortcode := cmd.Flag("emu-shortcode").Value.String()
+		sourceEmuShortcode := cmd.Flag("source-emu-shortcode").Value.String()
+		emuUnresolvedFile := cmd.Flag("emu-unresolved-file").Value.String()
+		teamSyncGroups := cmd.Flag("team-sync-groups").Value.String()
+		secretTeamPolicy := cmd.Flag("secret-team-policy").Value.String()
 
 		// Set ENV variables
 		os.Setenv("GHMT_TARGET_ORGANIZATION", targetOrganization)
 		os.Setenv("GHMT_SOURCE_TOKEN", sourceToken)
+		os.Setenv("GHMT_SOURCE_APP_ID", sourceAppId)
+		// Keep a private key set in the environment
+		if sourcePrivateKey != "" {
+			os.Setenv("GHMT_SOURCE_PRIVATE_KEY", sourcePrivateKey)
+		}
+		os.Setenv("GHMT_SOURCE_INSTALLATION_ID", sourceInstallationId)
 		os.Setenv("GHMT_TARGET_TOKEN", targetToken)
 		os.Setenv("GHMT_MAPPING_FILE", mappingFile)
+		os.Setenv("GHMT_MAPPING_FORMAT", mappingFormat)
+		// The mapping files were only read from the environment before these flags existed
+		if teamMappingFile != "" {
+			os.Setenv("GHMT_TEAM_MAPPING_FILE", teamMappingFile)
+		}
+		if repoMappingFile != "" {
+			os.Setenv("GHMT_REPO_MAPPING_FILE", repoMappingFile)
+		}
 		os.Setenv("GHMT_SOURCE_HOSTNAME", ghHostname)
+		os.Setenv("GHMT_TARGET_HOSTNAME", targetHostname)
 		os.Setenv("GHMT_REPO_FILE", repoFile)
 		os.Setenv("GHMT_SKIP_TEAMS", skipTeams)
+		os.Setenv("GHMT_PRUNE", prune)
+		os.Setenv("GHMT_PRUNE_LIMIT", pruneLimit)
+		os.Setenv("GHMT_PRUNE_ALLOWLIST", pruneAllowlist)
+		os.Setenv("GHMT_RECONCILE", reconcile)
 		os.Setenv("GHMT_INCLUDE_ALL_REPOS", strconv.FormatBool(includeAllRepos))
 		os.Setenv("GHMT_TARGET_APP_ID", tAppId)
 		os.Setenv("GHMT_TARGET_INSTALLATION_ID", tInstallationId)
+		os.Setenv("GHMT_CONCURRENCY", concurrency)
+		os.Setenv("GHMT_WRITE_INTERVAL", writeInterval)
+		os.Setenv("GHMT_STATE_FILE", stateFile)
+		os.Setenv("GHMT_RESUME", resume)
+		os.Setenv("GHMT_JOURNAL_FILE", journalFile)
+		os.Setenv("GHMT_REPORT_FILE", reportFile)
+		os.Setenv("GHMT_CREATE_CUSTOM_ROLES", createCustomRoles)
+		os.Setenv("GHMT_SYNC_COLLABORATORS", includeCollaborators)
+		os.Setenv("GHMT_RENAME_RULES_FILE", renameRules)
+		os.Setenv("GHMT_EXTERNAL_GROUP_MAPPING_FILE", externalGroupMappingFile)
+		os.Setenv("GHMT_IDP_GROUP_MAPPING_FILE", idpGroupMappingFile)
+		os.Setenv("GHMT_IDENTITY_MATCH", identityMatch)
+		os.Setenv("GHMT_IDENTITY_REVIEW_FILE", identityReviewFile)
+		os.Setenv("GHMT_TARGET_ENTERPRISE", targetEnterprise)
+		os.Setenv("GHMT_EMU_SHORTCODE", emuShortcode)
+		os.Setenv("GHMT_SOURCE_EMU_SHORTCODE", sourceEmuShortcode)
+		os.Setenv("GHMT_EMU_UNRESOLVED_FILE", emuUnresolvedFile)
+		os.Setenv("GHMT_TEAM_SYNC_GROUPS", teamSyncGroups)
+		os.Setenv("GHMT_SECRET_TEAM_POLICY", secretTeamPolicy)
 
 		// Bind ENV variables in Viper
 		viper.BindEnv("TARGET_ORGANIZATION")
 		viper.BindEnv("SOURCE_TOKEN")
+		viper.BindEnv("SOURCE_APP_ID")
+		viper.BindEnv("SOURCE_PRIVATE_KEY")
+		viper.BindEnv("SOURCE_INSTALLATION_ID")
 		viper.BindEnv("TARGET_TOKEN")
 		viper.BindEnv("MAPPING_FILE")
+		viper.BindEnv("MAPPING_FORMAT")
+		viper.BindEnv("TEAM_MAPPING_FILE")
+		viper.BindEnv("REPO_MAPPING_FILE")
 		viper.BindEnv("SOURCE_HOSTNAME")
+		viper.BindEnv("TARGET_HOSTNAME")
 		viper.BindEnv("USER_SYNC")
 		viper.BindEnv("SKIP_TEAMS")
+		viper.BindEnv("PRUNE")
+		viper.BindEnv("PRUNE_LIMIT")
+		viper.BindEnv("PRUNE_ALLOWLIST")
+		viper.BindEnv("RECONCILE")
 		viper.BindEnv("REPO_FILE")
 		viper.BindEnv("INCLUDE_ALL_REPOS")
 		viper.BindEnv("TARGET_PRIVATE_KEY")
 		viper.BindEnv("TARGET_APP_ID")
 		viper.BindEnv("TARGET_INSTALLATION_ID")
+		viper.BindEnv("CONCURRENCY")
+		viper.BindEnv("WRITE_INTERVAL")
+		viper.BindEnv("STATE_FILE")
+		viper.BindEnv("RESUME")
+		viper.BindEnv("JOURNAL_FILE")
+		viper.BindEnv("REPORT_FILE")
+		viper.BindEnv("CREATE_CUSTOM_ROLES")
+		viper.BindEnv("SYNC_COLLABORATORS")
+		viper.BindEnv("RENAME_RULES_FILE")
+		viper.BindEnv("EXTERNAL_GROUP_MAPPING_FILE")
+		viper.BindEnv("IDP_GROUP_MAPPING_FILE")
+		viper.BindEnv("IDENTITY_MATCH")
+		viper.BindEnv("IDENTITY_REVIEW_FILE")
+		viper.BindEnv("TARGET_ENTERPRISE")
+		viper.BindEnv("EMU_SHORTCODE")
+		viper.BindEnv("SOURCE_EMU_SHORTCODE")
+		viper.BindEnv("EMU_UNRESOLVED_FILE")
+		viper.BindEnv("TEAM_SYNC_GROUPS")
+		viper.BindEnv("SECRET_TEAM_POLICY")
+		bindTeamFilterFlags(cmd)
 
 		sync.SyncTeamsByRepo()
 	},
@@ -72,7 +171,12 @@ func init() {
 	byReposCmd.MarkFlagRequired("target-organization")
 
 	byReposCmd.Flags().StringP("source-token", "a", "", "Source Organization GitHub token. Scopes: read:org, read:user, user:email")
-	byReposCmd.MarkFlagRequired("source-token")
+
+	byReposCmd.Flags().String("source-app-id", "", "Source GitHub App ID, used instead of --source-token. Needs read access to organization members and administration, and to repository metadata")
+
+	byReposCmd.Flags().String("source-private-key", "", "Source GitHub App private key, or the path of its PEM file. Ideally set as an env variable: 'GHMT_SOURCE_PRIVATE_KEY'")
+
+	byReposCmd.Flags().Int64("source-installation-id", 0, "Source GitHub App installation ID. Found from the organization of each repository when not set")
 
 	byReposCmd.Flags().StringP("target-token", "b", "", "Target Organization GitHub token. Scopes: admin:org")
 
@@ -81,11 +185,23 @@ func init() {
 
 	byReposCmd.Flags().StringP("mapping-file", "m", "", "Mapping file path to use for mapping teams members handles")
 
+	byReposCmd.Flags().String("mapping-format", "", "Format of the mapping file: csv (source,target columns), gei (mannequin CSV of gh gei generate-mannequin-csv), json or yaml. Detected from the file extension and header when not set")
+
+	byReposCmd.Flags().String("team-mapping-file", "", "Mapping file renaming source teams, from owner/team-name or owner/team-slug to the new team name. Columns: source,target, or a JSON or YAML file")
+
+	byReposCmd.Flags().String("repo-mapping-file", "", "Mapping file renaming source repositories, from owner/repository to the repository name in the target organization or to owner/name. Columns: source,target, or a JSON or YAML file")
+
 	byReposCmd.Flags().BoolP("skip-teams", "k", false, "Skips adding members and repos to teams that already exist to save on API requests (default \"false\")")
 
+	byReposCmd.Flags().BoolP("reconcile", "c", false, "Compares existing target teams with the source and only makes the changes needed to match (default \"false\")")
+
 	byReposCmd.Flags().BoolP("include-all-repos", "r", false, "Include all repositories that teams had access to in source, not just those in the migration list (default \"false\")")
 
-	byReposCmd.Flags().StringP("source-hostname", "u", os.Getenv("SOURCE_HOST"), "GitHub Enterprise source hostname url (optional) Ex. https://github.example.com")
+	byReposCmd.Flags().Bool("include-collaborators", false, "Also recreates direct and outside collaborator access to the repositories in the list (default \"false\")")
+
+	byReposCmd.Flags().StringP("source-hostname", "u", os.Getenv("SOURCE_HOST"), "GitHub Enterprise Server or GHE.com source hostname (optional) Ex. https://github.example.com or acme.ghe.com")
+
+	byReposCmd.Flags().String("target-hostname", "", "GitHub Enterprise Server or GHE.com target hostname (optional) Ex. https://github.example.com or acme.ghe.com")
 
 	byReposCmd.Flags().StringP("target-private-key", "p", "", "Private key for GitHub App authentication. Ideally set as an env variable: 'GHMT_TARGET_PRIVATE_KEY'")
 	viper.BindPFlag("TARGET_PRIVATE_KEY", byReposCmd.Flags().Lookup("target-private-key"))
@@ -93,4 +209,48 @@ func init() {
 	byReposCmd.Flags().StringP("target-app-id", "i", "", "GitHub App ID")
 
 	byReposCmd.Flags().Int64P("target-installation-id", "l", 0, "GitHub App Installation ID")
+
+	byReposCmd.Flags().Bool("prune", false, "Removes members and repository access from existing target teams that no longer exist in the source (default \"false\")")
+
+	byReposCmd.Flags().Int("prune-limit", 100, "Maximum number of objects prune may remove in a single run")
+
+	byReposCmd.Flags().String("prune-allowlist", "", "File of objects prune must never remove, one per line. Ex. team:slug, member:slug/login, repository:slug/repo")
+
+	byReposCmd.Flags().IntP("concurrency", "w", 1, "Number of teams to process in parallel. Parent teams are always finished before their children")
+
+	byReposCmd.Flags().Duration("write-interval", 0, "Minimum time between write requests across all workers to avoid secondary rate limits. Ex. 1s")
+
+	byReposCmd.Flags().String("state-file", "gh-migrate-teams-state.jsonl", "File used to record progress so an interrupted run can be resumed. Removed when the run finishes without failed writes")
+
+	byReposCmd.Flags().Bool("resume", false, "Resumes an interrupted run from the state file, skipping completed work and source data already fetched (default \"false\")")
+
+	byReposCmd.Flags().StringP("journal-file", "j", "gh-migrate-teams-journal.jsonl", "Append-only file recording every write to the target organization, used by rollback")
+
+	byReposCmd.Flags().String("report-file", "gh-migrate-teams-report.json", "File to write the outcome of every operation to as JSON. A CSV copy is written next to it")
+
+	byReposCmd.Flags().String("rename-rules", "", "CSV file of ordered regex rewrites for team names, slugs, descriptions and repository names. Columns: field,pattern,replacement")
+
+	byReposCmd.Flags().String("external-group-mapping-file", "", "CSV file connecting teams to external IdP groups of an Enterprise Managed Users target organization. Columns: team,group")
+
+	byReposCmd.Flags().String("idp-group-mapping-file", "", "CSV file translating source IdP group IDs to the target IdP tenant. Columns: source_group_id,target_group_id")
+
+	byReposCmd.Flags().String("identity-match", "", "Comma separated ways to find the target user of each member, tried in order: email (organization verified domain emails), saml (SAML NameID). Members without a single match are not added")
+
+	byReposCmd.Flags().String("identity-review-file", "gh-migrate-teams-identity-review.csv", "CSV file listing the members --identity-match found no single target user for")
+
+	byReposCmd.Flags().String("target-enterprise", "", "Target enterprise slug to read SAML identities from when SAML is configured for the enterprise, such as with Enterprise Managed Users")
+
+	byReposCmd.Flags().String("emu-shortcode", "", "Enterprise shortcode of the target Enterprise Managed Users enterprise, target logins of members that are not in the mapping file are derived as <normalized login>_<shortcode>")
+
+	byReposCmd.Flags().String("source-emu-shortcode", "", "Enterprise shortcode of the source Enterprise Managed Users enterprise, removed from source logins before target logins are derived with --emu-shortcode")
+
+	byReposCmd.Flags().String("emu-unresolved-file", "gh-migrate-teams-emu-unresolved.csv", "CSV file listing the members whose login derived with --emu-shortcode is not a member of the target organization")
+
+	byReposCmd.Flags().Bool("team-sync-groups", false, "Reads the team synchronization IdP groups connected to each source team, which needs a source token of an organization owner (default \"false\")")
+
+	addTeamFilterFlags(byReposCmd)
+
+	byReposCmd.Flags().Bool("create-custom-roles", false, "Creates custom repository roles granted to teams that do not exist in the target organization from their source definition (default \"false\")")
+
+	byReposCmd.Flags().String("secret-team-policy", "closed", "How to handle secret teams with a parent or child teams, which GitHub does not allow. One of: closed, drop-parent, fail")
 }
@@ -0,0 +1,149 @@
+/*
+Copyright © 2024 NAME HERE <EMAIL ADDRESS>
+*/
+package cmd
+
+import (
+	"os"
+
+	"github.com/mona-actions/gh-migrate-teams/pkg/sync"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+// collaboratorsCmd represents the collaborators command
+var collaboratorsCmd = &cobra.Command{
+	Use:   "collaborators",
+	Short: "Recreates direct and outside collaborator access to repositories",
+	Long: `Recreates direct and outside collaborator access from the repositories of a source organization
+	on the repositories of the same name in a target organization.
+
+	Users that are not members of the target organization are sent an invitation, invitations that
+	have not been accepted yet are listed at the end of the run.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		// Get parameters
+		sourceOrganization := cmd.Flag("source-organization").Value.String()
+		targetOrganization := cmd.Flag("target-organization").Value.String()
+		sourceToken := cmd.Flag("source-token").Value.String()
+		targetToken := cmd.Flag("target-token").Value.String()
+		mappingFile := cmd.Flag("mapping-file").Value.String()
+		mappingFormat := cmd.Flag("mapping-format").Value.String()
+		repoMappingFile := cmd.Flag("repo-mapping-file").Value.String()
+		ghHostname := cmd.Flag("source-hostname").Value.String()
+		targetHostname := cmd.Flag("target-hostname").Value.String()
+		renameRules := cmd.Flag("rename-rules").Value.String()
+		identityMatch := cmd.Flag("identity-match").Value.String()
+		identityReviewFile := cmd.Flag("identity-review-file").Value.String()
+		targetEnterprise := cmd.Flag("target-enterprise").Value.String()
+		emuShortcode := cmd.Flag("emu-shortcode").Value.String()
+		sourceEmuShortcode := cmd.Flag("source-emu-shortcode").Value.String()
+		concurrency := cmd.Flag("concurrency").Value.String()
+		writeInterval := cmd.Flag("write-interval").Value.String()
+		stateFile := cmd.Flag("state-file").Value.String()
+		resume := cmd.Flag("resume").Value.String()
+		journalFile := cmd.Flag("journal-file").Value.String()
+		reportFile := cmd.Flag("report-file").Value.String()
+
+		// Set ENV variables
+		os.Setenv("GHMT_SOURCE_ORGANIZATION", sourceOrganization)
+		os.Setenv("GHMT_TARGET_ORGANIZATION", targetOrganization)
+		os.Setenv("GHMT_SOURCE_TOKEN", sourceToken)
+		os.Setenv("GHMT_TARGET_TOKEN", targetToken)
+		os.Setenv("GHMT_MAPPING_FILE", mappingFile)
+		os.Setenv("GHMT_MAPPING_FORMAT", mappingFormat)
+		if repoMappingFile != "" {
+			os.Setenv("GHMT_REPO_MAPPING_FILE", repoMappingFile)
+		}
+		os.Setenv("GHMT_SOURCE_HOSTNAME", ghHostname)
+		os.Setenv("GHMT_TARGET_HOSTNAME", targetHostname)
+		os.Setenv("GHMT_RENAME_RULES_FILE", renameRules)
+		os.Setenv("GHMT_IDENTITY_MATCH", identityMatch)
+		os.Setenv("GHMT_IDENTITY_REVIEW_FILE", identityReviewFile)
+		os.Setenv("GHMT_TARGET_ENTERPRISE", targetEnterprise)
+		os.Setenv("GHMT_EMU_SHORTCODE", emuShortcode)
+		os.Setenv("GHMT_SOURCE_EMU_SHORTCODE", sourceEmuShortcode)
+		os.Setenv("GHMT_CONCURRENCY", concurrency)
+		os.Setenv("GHMT_WRITE_INTERVAL", writeInterval)
+		os.Setenv("GHMT_STATE_FILE", stateFile)
+		os.Setenv("GHMT_RESUME", resume)
+		os.Setenv("GHMT_JOURNAL_FILE", journalFile)
+		os.Setenv("GHMT_REPORT_FILE", reportFile)
+
+		// Bind ENV variables in Viper
+		viper.BindEnv("SOURCE_ORGANIZATION")
+		viper.BindEnv("TARGET_ORGANIZATION")
+		viper.BindEnv("SOURCE_TOKEN")
+		viper.BindEnv("TARGET_TOKEN")
+		viper.BindEnv("MAPPING_FILE")
+		viper.BindEnv("MAPPING_FORMAT")
+		viper.BindEnv("REPO_MAPPING_FILE")
+		viper.BindEnv("SOURCE_HOSTNAME")
+		viper.BindEnv("TARGET_HOSTNAME")
+		viper.BindEnv("RENAME_RULES_FILE")
+		viper.BindEnv("IDENTITY_MATCH")
+		viper.BindEnv("IDENTITY_REVIEW_FILE")
+		viper.BindEnv("TARGET_ENTERPRISE")
+		viper.BindEnv("EMU_SHORTCODE")
+		viper.BindEnv("SOURCE_EMU_SHORTCODE")
+		viper.BindEnv("CONCURRENCY")
+		viper.BindEnv("WRITE_INTERVAL")
+		viper.BindEnv("STATE_FILE")
+		viper.BindEnv("RESUME")
+		viper.BindEnv("JOURNAL_FILE")
+		viper.BindEnv("REPORT_FILE")
+
+		// Call syncCollaborators
+		sync.SyncCollaborators()
+	},
+}
+
+func init() {
+	syncCmd.AddCommand(collaboratorsCmd)
+
+	// Flags
+	collaboratorsCmd.Flags().StringP("source-organization", "s", "", "Source Organization to sync collaborators from")
+	collaboratorsCmd.MarkFlagRequired("source-organization")
+
+	collaboratorsCmd.Flags().StringP("target-organization", "t", "", "Target Organization to sync collaborators to")
+	collaboratorsCmd.MarkFlagRequired("target-organization")
+
+	collaboratorsCmd.Flags().StringP("source-token", "a", "", "Source Organization GitHub token. Scopes: repo, read:org")
+	collaboratorsCmd.MarkFlagRequired("source-token")
+
+	collaboratorsCmd.Flags().StringP("target-token", "b", "", "Target Organization GitHub token. Scopes: repo, admin:org")
+	collaboratorsCmd.MarkFlagRequired("target-token")
+
+	collaboratorsCmd.Flags().StringP("mapping-file", "m", "", "Mapping file path to use for mapping collaborator handles")
+
+	collaboratorsCmd.Flags().String("mapping-format", "", "Format of the mapping file: csv (source,target columns), gei (mannequin CSV of gh gei generate-mannequin-csv), json or yaml. Detected from the file extension and header when not set")
+
+	collaboratorsCmd.Flags().String("repo-mapping-file", "", "Mapping file renaming source repositories, from owner/repository to the repository name in the target organization or to owner/name. Columns: source,target, or a JSON or YAML file")
+
+	collaboratorsCmd.Flags().StringP("source-hostname", "u", "", "GitHub Enterprise Server or GHE.com source hostname (optional) Ex. https://github.example.com or acme.ghe.com")
+
+	collaboratorsCmd.Flags().String("target-hostname", "", "GitHub Enterprise Server or GHE.com target hostname (optional) Ex. https://github.example.com or acme.ghe.com")
+
+	collaboratorsCmd.Flags().String("rename-rules", "", "CSV file of ordered regex rewrites. Only repository rules apply. Columns: field,pattern,replacement")
+
+	collaboratorsCmd.Flags().String("identity-match", "", "Comma separated ways to find the target user of each collaborator, tried in order: email (organization verified domain emails), saml (SAML NameID). Collaborators without a single match are not added")
+
+	collaboratorsCmd.Flags().String("identity-review-file", "gh-migrate-teams-identity-review.csv", "CSV file listing the collaborators --identity-match found no single target user for")
+
+	collaboratorsCmd.Flags().String("target-enterprise", "", "Target enterprise slug to read SAML identities from when SAML is configured for the enterprise, such as with Enterprise Managed Users")
+
+	collaboratorsCmd.Flags().String("emu-shortcode", "", "Enterprise shortcode of the target Enterprise Managed Users enterprise, target logins of collaborators that are not in the mapping file are derived as <normalized login>_<shortcode>")
+
+	collaboratorsCmd.Flags().String("source-emu-shortcode", "", "Enterprise shortcode of the source Enterprise Managed Users enterprise, removed from source logins before target logins are derived with --emu-shortcode")
+
+	collaboratorsCmd.Flags().IntP("concurrency", "w", 1, "Number of repositories to process in parallel")
+
+	collaboratorsCmd.Flags().Duration("write-interval", 0, "Minimum time between write requests across all workers to avoid secondary rate limits. Ex. 1s")
+
+	collaboratorsCmd.Flags().String("state-file", "gh-migrate-teams-state.jsonl", "File used to record progress so an interrupted run can be resumed. Removed when the run finishes without failed writes")
+
+	collaboratorsCmd.Flags().Bool("resume", false, "Resumes an interrupted run from the state file, skipping completed work and source data already fetched (default \"false\")")
+
+	collaboratorsCmd.Flags().StringP("journal-file", "j", "gh-migrate-teams-journal.jsonl", "Append-only file recording every write to the target organization, used by rollback")
+
+	collaboratorsCmd.Flags().String("report-file", "gh-migrate-teams-report.json", "File to write the outcome of every operation to as JSON. A CSV copy is written next to it")
+}
@@ -20,8 +20,16 @@ var exportCmd = &cobra.Command{
 		// Get parameters
 		organization := cmd.Flag("organization").Value.String()
 		token := cmd.Flag("token").Value.String()
+		appId := cmd.Flag("app-id").Value.String()
+		privateKey := cmd.Flag("private-key").Value.String()
+		installationId := cmd.Flag("installation-id").Value.String()
 		filePrefix := cmd.Flag("file-prefix").Value.String()
 		ghHostname := cmd.Flag("hostname").Value.String()
+		renameRules := cmd.Flag("preview-renames").Value.String()
+		teamSyncGroups := cmd.Flag("team-sync-groups").Value.String()
+		reclaimCsv := cmd.Flag("reclaim-csv").Value.String()
+		teamMappingFile := cmd.Flag("team-mapping-file").Value.String()
+		repoMappingFile := cmd.Flag("repo-mapping-file").Value.String()
 		if filePrefix == "" {
 			filePrefix = organization
 		}
@@ -29,14 +37,39 @@ var exportCmd = &cobra.Command{
 		// Set ENV variables
 		os.Setenv("GHMT_SOURCE_ORGANIZATION", organization)
 		os.Setenv("GHMT_SOURCE_TOKEN", token)
+		os.Setenv("GHMT_SOURCE_APP_ID", appId)
+		// Keep a private key set in the environment
+		if privateKey != "" {
+			os.Setenv("GHMT_SOURCE_PRIVATE_KEY", privateKey)
+		}
+		os.Setenv("GHMT_SOURCE_INSTALLATION_ID", installationId)
 		os.Setenv("GHMT_OUTPUT_FILE", filePrefix)
 		os.Setenv("GHMT_SOURCE_HOSTNAME", ghHostname)
+		os.Setenv("GHMT_RENAME_RULES_FILE", renameRules)
+		os.Setenv("GHMT_TEAM_SYNC_GROUPS", teamSyncGroups)
+		os.Setenv("GHMT_RECLAIM_CSV", reclaimCsv)
+		// The mapping files were only read from the environment before these flags existed
+		if teamMappingFile != "" {
+			os.Setenv("GHMT_TEAM_MAPPING_FILE", teamMappingFile)
+		}
+		if repoMappingFile != "" {
+			os.Setenv("GHMT_REPO_MAPPING_FILE", repoMappingFile)
+		}
 
 		// Bind ENV variables in Viper
 		viper.BindEnv("SOURCE_ORGANIZATION")
 		viper.BindEnv("SOURCE_TOKEN")
+		viper.BindEnv("SOURCE_APP_ID")
+		viper.BindEnv("SOURCE_PRIVATE_KEY")
+		viper.BindEnv("SOURCE_INSTALLATION_ID")
 		viper.BindEnv("OUTPUT_FILE")
 		viper.BindEnv("SOURCE_HOSTNAME")
+		viper.BindEnv("RENAME_RULES_FILE")
+		viper.BindEnv("TEAM_SYNC_GROUPS")
+		viper.BindEnv("RECLAIM_CSV")
+		viper.BindEnv("TEAM_MAPPING_FILE")
+		viper.BindEnv("REPO_MAPPING_FILE")
+		bindTeamFilterFlags(cmd)
 
 		// Call exportCSV
 		export.CreateCSVs()
@@ -51,10 +84,26 @@ func init() {
 	exportCmd.MarkFlagRequired("organization")
 
 	exportCmd.Flags().StringP("token", "t", "", "GitHub token")
-	exportCmd.MarkFlagRequired("token")
+
+	exportCmd.Flags().String("app-id", "", "GitHub App ID, used instead of --token. Needs read access to organization members and administration, and to repository metadata")
+
+	exportCmd.Flags().String("private-key", "", "GitHub App private key, or the path of its PEM file. Ideally set as an env variable: 'GHMT_SOURCE_PRIVATE_KEY'")
+
+	exportCmd.Flags().Int64("installation-id", 0, "GitHub App installation ID. Found from --organization when not set")
 
 	exportCmd.Flags().StringP("file-prefix", "f", "", "Output filenames prefix")
 
-	exportCmd.Flags().StringP("hostname", "u", "", "GitHub Enterprise hostname url (optional) Ex. https://github.example.com")
+	exportCmd.Flags().StringP("hostname", "u", "", "GitHub Enterprise Server or GHE.com hostname (optional) Ex. https://github.example.com or acme.ghe.com")
+
+	exportCmd.Flags().String("preview-renames", "", "Rename rules file to preview. Writes the values the rules would change to <file-prefix>-rename-preview.csv")
+
+	exportCmd.Flags().Bool("team-sync-groups", false, "Exports the team synchronization IdP groups connected to each team to <file-prefix>-team-idp-groups.csv, which needs a token of an organization owner (default \"false\")")
+
+	exportCmd.Flags().Bool("reclaim-csv", false, "Writes every team member to <file-prefix>-reclaim.csv in the layout of gh gei reclaim-mannequin, which can also be used as a --mapping-file (default \"false\")")
+
+	exportCmd.Flags().String("team-mapping-file", "", "Mapping file renaming source teams, from owner/team-name or owner/team-slug to the new team name. Columns: source,target, or a JSON or YAML file")
+
+	exportCmd.Flags().String("repo-mapping-file", "", "Mapping file renaming source repositories, from owner/repository to the repository name in the target organization or to owner/name. Columns: source,target, or a JSON or YAML file")
 
+	addTeamFilterFlags(exportCmd)
 }
@@ -0,0 +1,46 @@
+package cmd
+
+import (
+	"os"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+// addTeamFilterFlags adds the team selection flags shared by export, sync, sync byRepos and plan
+func addTeamFilterFlags(cmd *cobra.Command) {
+	cmd.Flags().String("include-teams", "", "Comma separated team slugs to include. Accepts globs, or regular expressions wrapped in slashes. Ex. eng-*,/^ops-[0-9]+$/")
+
+	cmd.Flags().String("exclude-teams", "", "Comma separated team slugs to exclude. Accepts globs, or regular expressions wrapped in slashes")
+
+	cmd.Flags().String("team-list", "", "File of team slugs or patterns to include, one per line")
+
+	cmd.Flags().String("descendants-of", "", "Comma separated team slugs to include together with all of their child teams")
+
+	cmd.Flags().Int("min-members", 0, "Only include teams with at least this many members")
+
+	cmd.Flags().Int("max-members", 0, "Only include teams with at most this many members (default no limit)")
+
+	cmd.Flags().String("repository-pattern", "", "Comma separated repository names, only teams with access to a matching repository are included. Accepts globs, or regular expressions wrapped in slashes")
+}
+
+// bindTeamFilterFlags exposes the team selection flags to Viper
+func bindTeamFilterFlags(cmd *cobra.Command) {
+	// Set ENV variables
+	os.Setenv("GHMT_TEAM_INCLUDE", cmd.Flag("include-teams").Value.String())
+	os.Setenv("GHMT_TEAM_EXCLUDE", cmd.Flag("exclude-teams").Value.String())
+	os.Setenv("GHMT_TEAM_LIST_FILE", cmd.Flag("team-list").Value.String())
+	os.Setenv("GHMT_TEAM_DESCENDANTS_OF", cmd.Flag("descendants-of").Value.String())
+	os.Setenv("GHMT_TEAM_MIN_MEMBERS", cmd.Flag("min-members").Value.String())
+	os.Setenv("GHMT_TEAM_MAX_MEMBERS", cmd.Flag("max-members").Value.String())
+	os.Setenv("GHMT_TEAM_REPOSITORY", cmd.Flag("repository-pattern").Value.String())
+
+	// Bind ENV variables in Viper
+	viper.BindEnv("TEAM_INCLUDE")
+	viper.BindEnv("TEAM_EXCLUDE")
+	viper.BindEnv("TEAM_LIST_FILE")
+	viper.BindEnv("TEAM_DESCENDANTS_OF")
+	viper.BindEnv("TEAM_MIN_MEMBERS")
+	viper.BindEnv("TEAM_MAX_MEMBERS")
+	viper.BindEnv("TEAM_REPOSITORY")
+}
@@ -0,0 +1,180 @@
+/*
+Copyright © 2024 NAME HERE <EMAIL ADDRESS>
+*/
+package cmd
+
+import (
+	"os"
+
+	"github.com/mona-actions/gh-migrate-teams/pkg/sync"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+// importCmd represents the import command
+var importCmd = &cobra.Command{
+	Use:   "import",
+	Short: "Recreates teams, membership, and team repo roles from the CSV files created by export",
+	Long: `Recreates teams, membership, and team repo roles from the CSV files created by export.
+
+	Reads <prefix>-teams.csv (optional), <prefix>-team-membership.csv and <prefix>-team-repositories.csv,
+	so teams can be migrated without access to the source organization and edited by hand first.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		// Get parameters
+		fromPrefix := cmd.Flag("from-prefix").Value.String()
+		targetOrganization := cmd.Flag("target-organization").Value.String()
+		targetToken := cmd.Flag("target-token").Value.String()
+		targetHostname := cmd.Flag("target-hostname").Value.String()
+		mappingFile := cmd.Flag("mapping-file").Value.String()
+		mappingFormat := cmd.Flag("mapping-format").Value.String()
+		userSync := cmd.Flag("user-sync").Value.String()
+		skipTeams := cmd.Flag("skip-teams").Value.String()
+		reconcile := cmd.Flag("reconcile").Value.String()
+		prune := cmd.Flag("prune").Value.String()
+		pruneLimit := cmd.Flag("prune-limit").Value.String()
+		pruneAllowlist := cmd.Flag("prune-allowlist").Value.String()
+		concurrency := cmd.Flag("concurrency").Value.String()
+		writeInterval := cmd.Flag("write-interval").Value.String()
+		stateFile := cmd.Flag("state-file").Value.String()
+		resume := cmd.Flag("resume").Value.String()
+		journalFile := cmd.Flag("journal-file").Value.String()
+		reportFile := cmd.Flag("report-file").Value.String()
+		renameRules := cmd.Flag("rename-rules").Value.String()
+		externalGroupMappingFile := cmd.Flag("external-group-mapping-file").Value.String()
+		idpGroupMappingFile := cmd.Flag("idp-group-mapping-file").Value.String()
+		identityMatch := cmd.Flag("identity-match").Value.String()
+		identityReviewFile := cmd.Flag("identity-review-file").Value.String()
+		targetEnterprise := cmd.Flag("target-enterprise").Value.String()
+		emuShortcode := cmd.Flag("emu-shortcode").Value.String()
+		sourceEmuShortcode := cmd.Flag("source-emu-shortcode").Value.String()
+		emuUnresolvedFile := cmd.Flag("emu-unresolved-file").Value.String()
+		secretTeamPolicy := cmd.Flag("secret-team-policy").Value.String()
+
+		// Set ENV variables
+		os.Setenv("GHMT_IMPORT_PREFIX", fromPrefix)
+		os.Setenv("GHMT_TARGET_ORGANIZATION", targetOrganization)
+		os.Setenv("GHMT_TARGET_TOKEN", targetToken)
+		os.Setenv("GHMT_TARGET_HOSTNAME", targetHostname)
+		os.Setenv("GHMT_MAPPING_FILE", mappingFile)
+		os.Setenv("GHMT_MAPPING_FORMAT", mappingFormat)
+		os.Setenv("GHMT_USER_SYNC", userSync)
+		os.Setenv("GHMT_SKIP_TEAMS", skipTeams)
+		os.Setenv("GHMT_RECONCILE", reconcile)
+		os.Setenv("GHMT_PRUNE", prune)
+		os.Setenv("GHMT_PRUNE_LIMIT", pruneLimit)
+		os.Setenv("GHMT_PRUNE_ALLOWLIST", pruneAllowlist)
+		os.Setenv("GHMT_CONCURRENCY", concurrency)
+		os.Setenv("GHMT_WRITE_INTERVAL", writeInterval)
+		os.Setenv("GHMT_STATE_FILE", stateFile)
+		os.Setenv("GHMT_RESUME", resume)
+		os.Setenv("GHMT_JOURNAL_FILE", journalFile)
+		os.Setenv("GHMT_REPORT_FILE", reportFile)
+		os.Setenv("GHMT_RENAME_RULES_FILE", renameRules)
+		os.Setenv("GHMT_EXTERNAL_GROUP_MAPPING_FILE", externalGroupMappingFile)
+		os.Setenv("GHMT_IDP_GROUP_MAPPING_FILE", idpGroupMappingFile)
+		os.Setenv("GHMT_IDENTITY_MATCH", identityMatch)
+		os.Setenv("GHMT_IDENTITY_REVIEW_FILE", identityReviewFile)
+		os.Setenv("GHMT_TARGET_ENTERPRISE", targetEnterprise)
+		os.Setenv("GHMT_EMU_SHORTCODE", emuShortcode)
+		os.Setenv("GHMT_SOURCE_EMU_SHORTCODE", sourceEmuShortcode)
+		os.Setenv("GHMT_EMU_UNRESOLVED_FILE", emuUnresolvedFile)
+		os.Setenv("GHMT_SECRET_TEAM_POLICY", secretTeamPolicy)
+
+		// Bind ENV variables in Viper
+		viper.BindEnv("IMPORT_PREFIX")
+		viper.BindEnv("TARGET_ORGANIZATION")
+		viper.BindEnv("TARGET_TOKEN")
+		viper.BindEnv("TARGET_HOSTNAME")
+		viper.BindEnv("MAPPING_FILE")
+		viper.BindEnv("MAPPING_FORMAT")
+		viper.BindEnv("USER_SYNC")
+		viper.BindEnv("SKIP_TEAMS")
+		viper.BindEnv("RECONCILE")
+		viper.BindEnv("PRUNE")
+		viper.BindEnv("PRUNE_LIMIT")
+		viper.BindEnv("PRUNE_ALLOWLIST")
+		viper.BindEnv("CONCURRENCY")
+		viper.BindEnv("WRITE_INTERVAL")
+		viper.BindEnv("STATE_FILE")
+		viper.BindEnv("RESUME")
+		viper.BindEnv("JOURNAL_FILE")
+		viper.BindEnv("REPORT_FILE")
+		viper.BindEnv("RENAME_RULES_FILE")
+		viper.BindEnv("EXTERNAL_GROUP_MAPPING_FILE")
+		viper.BindEnv("IDP_GROUP_MAPPING_FILE")
+		viper.BindEnv("IDENTITY_MATCH")
+		viper.BindEnv("IDENTITY_REVIEW_FILE")
+		viper.BindEnv("TARGET_ENTERPRISE")
+		viper.BindEnv("EMU_SHORTCODE")
+		viper.BindEnv("SOURCE_EMU_SHORTCODE")
+		viper.BindEnv("EMU_UNRESOLVED_FILE")
+		viper.BindEnv("SECRET_TEAM_POLICY")
+
+		// Call importTeams
+		sync.ImportTeams()
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(importCmd)
+
+	// Flags
+	importCmd.Flags().StringP("from-prefix", "f", "", "Filenames prefix of the CSV files created by export")
+	importCmd.MarkFlagRequired("from-prefix")
+
+	importCmd.Flags().StringP("target-organization", "t", "", "Target Organization to import teams to")
+	importCmd.MarkFlagRequired("target-organization")
+
+	importCmd.Flags().StringP("target-token", "b", "", "Target Organization GitHub token. Scopes: admin:org")
+	importCmd.MarkFlagRequired("target-token")
+
+	importCmd.Flags().String("target-hostname", "", "GitHub Enterprise Server or GHE.com target hostname (optional) Ex. https://github.example.com or acme.ghe.com")
+
+	importCmd.Flags().StringP("mapping-file", "m", "", "Mapping file path to use for mapping teams members handles")
+
+	importCmd.Flags().String("mapping-format", "", "Format of the mapping file: csv (source,target columns), gei (mannequin CSV of gh gei generate-mannequin-csv), json or yaml. Detected from the file extension and header when not set")
+
+	importCmd.Flags().StringP("user-sync", "z", "all", "User sync mode. One of: all, disable")
+
+	importCmd.Flags().BoolP("skip-teams", "k", false, "Skips adding members and repos to teams that already exist to save on API requests (default \"false\")")
+
+	importCmd.Flags().BoolP("reconcile", "c", false, "Compares existing target teams with the CSV files and only makes the changes needed to match (default \"false\")")
+
+	importCmd.Flags().Bool("prune", false, "Removes members and repository access from existing target teams that are not in the CSV files (default \"false\")")
+
+	importCmd.Flags().Int("prune-limit", 100, "Maximum number of objects prune may remove in a single run")
+
+	importCmd.Flags().String("prune-allowlist", "", "File of objects prune must never remove, one per line. Ex. team:slug, member:slug/login, repository:slug/repo")
+
+	importCmd.Flags().IntP("concurrency", "w", 1, "Number of teams to process in parallel. Parent teams are always finished before their children")
+
+	importCmd.Flags().Duration("write-interval", 0, "Minimum time between write requests across all workers to avoid secondary rate limits. Ex. 1s")
+
+	importCmd.Flags().String("state-file", "gh-migrate-teams-state.jsonl", "File used to record progress so an interrupted run can be resumed. Removed when the run finishes without failed writes")
+
+	importCmd.Flags().Bool("resume", false, "Resumes an interrupted run from the state file, skipping completed work (default \"false\")")
+
+	importCmd.Flags().StringP("journal-file", "j", "gh-migrate-teams-journal.jsonl", "Append-only file recording every write to the target organization, used by rollback")
+
+	importCmd.Flags().String("report-file", "gh-migrate-teams-report.json", "File to write the outcome of every operation to as JSON. A CSV copy is written next to it")
+
+	importCmd.Flags().String("rename-rules", "", "CSV file of ordered regex rewrites for team names, slugs, descriptions and repository names. Columns: field,pattern,replacement")
+
+	importCmd.Flags().String("external-group-mapping-file", "", "CSV file connecting teams to external IdP groups of an Enterprise Managed Users target organization. Columns: team,group")
+
+	importCmd.Flags().String("idp-group-mapping-file", "", "CSV file translating source IdP group IDs to the target IdP tenant. Columns: source_group_id,target_group_id")
+
+	importCmd.Flags().String("identity-match", "", "Comma separated ways to find the target user of each member, tried in order: email (organization verified domain emails), saml (SAML NameID). Members without a single match are not added")
+
+	importCmd.Flags().String("identity-review-file", "gh-migrate-teams-identity-review.csv", "CSV file listing the members --identity-match found no single target user for")
+
+	importCmd.Flags().String("target-enterprise", "", "Target enterprise slug to read SAML identities from when SAML is configured for the enterprise, such as with Enterprise Managed Users")
+
+	importCmd.Flags().String("emu-shortcode", "", "Enterprise shortcode of the target Enterprise Managed Users enterprise, target logins of members that are not in the mapping file are derived as <normalized login>_<shortcode>")
+
+	importCmd.Flags().String("source-emu-shortcode", "", "Enterprise shortcode of the source Enterprise Managed Users enterprise, removed from source logins before target logins are derived with --emu-shortcode")
+
+	importCmd.Flags().String("emu-unresolved-file", "gh-migrate-teams-emu-unresolved.csv", "CSV file listing the members whose login derived with --emu-shortcode is not a member of the target organization")
+
+	importCmd.Flags().String("secret-team-policy", "closed", "How to handle secret teams with a parent or child teams, which GitHub does not allow. One of: closed, drop-parent, fail")
+}
@@ -0,0 +1,210 @@
+/*
+Copyright © 2024 NAME HERE <EMAIL ADDRESS>
+*/
+package cmd
+
+import (
+	"os"
+
+	"github.com/mona-actions/gh-migrate-teams/pkg/sync"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+// planCmd represents the plan command
+var planCmd = &cobra.Command{
+	Use:   "plan",
+	Short: "Creates a reviewable plan of the changes sync would make to a target organization",
+	Long: `Creates a reviewable plan of the changes sync would make to a target organization.
+
+	The plan lists every team creation, member change and repository grant and can be applied with the apply command.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		// Get parameters
+		sourceOrganization := cmd.Flag("source-organization").Value.String()
+		targetOrganization := cmd.Flag("target-organization").Value.String()
+		sourceToken := cmd.Flag("source-token").Value.String()
+		sourceAppId := cmd.Flag("source-app-id").Value.String()
+		sourcePrivateKey := cmd.Flag("source-private-key").Value.String()
+		sourceInstallationId := cmd.Flag("source-installation-id").Value.String()
+		targetToken := cmd.Flag("target-token").Value.String()
+		mappingFile := cmd.Flag("mapping-file").Value.String()
+		mappingFormat := cmd.Flag("mapping-format").Value.String()
+		teamMappingFile := cmd.Flag("team-mapping-file").Value.String()
+		repoMappingFile := cmd.Flag("repo-mapping-file").Value.String()
+		ghHostname := cmd.Flag("source-hostname").Value.String()
+		targetHostname := cmd.Flag("target-hostname").Value.String()
+		userSync := cmd.Flag("user-sync").Value.String()
+		skipTeams := cmd.Flag("skip-teams").Value.String()
+		prune := cmd.Flag("prune").Value.String()
+		pruneLimit := cmd.Flag("prune-limit").Value.String()
+		pruneAllowlist := cmd.Flag("prune-allowlist").Value.String()
+		pruneTeams := cmd.Flag("prune-teams").Value.String()
+		planFile := cmd.Flag("output").Value.String()
+		concurrency := cmd.Flag("concurrency").Value.String()
+		renameRules := cmd.Flag("rename-rules").Value.String()
+		externalGroupMappingFile := cmd.Flag("external-group-mapping-file").Value.String()
+		idpGroupMappingFile := cmd.Flag("idp-group-mapping-file").Value.String()
+		identityMatch := cmd.Flag("identity-match").Value.String()
+		identityReviewFile := cmd.Flag("identity-review-file").Value.String()
+		targetEnterprise := cmd.Flag("target-enterprise").Value.String()
+		emuShortcode := cmd.Flag("emu-shortcode").Value.String()
+		sourceEmuShortcode := cmd.Flag("source-emu-shortcode").Value.String()
+		emuUnresolvedFile := cmd.Flag("emu-unresolved-file").Value.String()
+		teamSyncGroups := cmd.Flag("team-sync-groups").Value.String()
+		secretTeamPolicy := cmd.Flag("secret-team-policy").Value.String()
+
+		// Set ENV variables
+		os.Setenv("GHMT_SOURCE_ORGANIZATION", sourceOrganization)
+		os.Setenv("GHMT_TARGET_ORGANIZATION", targetOrganization)
+		os.Setenv("GHMT_SOURCE_TOKEN", sourceToken)
+		os.Setenv("GHMT_SOURCE_APP_ID", sourceAppId)
+		// Keep a private key set in the environment
+		if sourcePrivateKey != "" {
+			os.Setenv("GHMT_SOURCE_PRIVATE_KEY", sourcePrivateKey)
+		}
+		os.Setenv("GHMT_SOURCE_INSTALLATION_ID", sourceInstallationId)
+		os.Setenv("GHMT_TARGET_TOKEN", targetToken)
+		os.Setenv("GHMT_MAPPING_FILE", mappingFile)
+		os.Setenv("GHMT_MAPPING_FORMAT", mappingFormat)
+		// The mapping files were only read from the environment before these flags existed
+		if teamMappingFile != "" {
+			os.Setenv("GHMT_TEAM_MAPPING_FILE", teamMappingFile)
+		}
+		if repoMappingFile != "" {
+			os.Setenv("GHMT_REPO_MAPPING_FILE", repoMappingFile)
+		}
+		os.Setenv("GHMT_SOURCE_HOSTNAME", ghHostname)
+		os.Setenv("GHMT_TARGET_HOSTNAME", targetHostname)
+		os.Setenv("GHMT_USER_SYNC", userSync)
+		os.Setenv("GHMT_SKIP_TEAMS", skipTeams)
+		os.Setenv("GHMT_PRUNE", prune)
+		os.Setenv("GHMT_PRUNE_LIMIT", pruneLimit)
+		os.Setenv("GHMT_PRUNE_ALLOWLIST", pruneAllowlist)
+		os.Setenv("GHMT_PRUNE_TEAMS", pruneTeams)
+		os.Setenv("GHMT_PLAN_FILE", planFile)
+		os.Setenv("GHMT_CONCURRENCY", concurrency)
+		os.Setenv("GHMT_RENAME_RULES_FILE", renameRules)
+		os.Setenv("GHMT_EXTERNAL_GROUP_MAPPING_FILE", externalGroupMappingFile)
+		os.Setenv("GHMT_IDP_GROUP_MAPPING_FILE", idpGroupMappingFile)
+		os.Setenv("GHMT_IDENTITY_MATCH", identityMatch)
+		os.Setenv("GHMT_IDENTITY_REVIEW_FILE", identityReviewFile)
+		os.Setenv("GHMT_TARGET_ENTERPRISE", targetEnterprise)
+		os.Setenv("GHMT_EMU_SHORTCODE", emuShortcode)
+		os.Setenv("GHMT_SOURCE_EMU_SHORTCODE", sourceEmuShortcode)
+		os.Setenv("GHMT_EMU_UNRESOLVED_FILE", emuUnresolvedFile)
+		os.Setenv("GHMT_TEAM_SYNC_GROUPS", teamSyncGroups)
+		os.Setenv("GHMT_SECRET_TEAM_POLICY", secretTeamPolicy)
+
+		// Bind ENV variables in Viper
+		viper.BindEnv("SOURCE_ORGANIZATION")
+		viper.BindEnv("TARGET_ORGANIZATION")
+		viper.BindEnv("SOURCE_TOKEN")
+		viper.BindEnv("SOURCE_APP_ID")
+		viper.BindEnv("SOURCE_PRIVATE_KEY")
+		viper.BindEnv("SOURCE_INSTALLATION_ID")
+		viper.BindEnv("TARGET_TOKEN")
+		viper.BindEnv("MAPPING_FILE")
+		viper.BindEnv("MAPPING_FORMAT")
+		viper.BindEnv("TEAM_MAPPING_FILE")
+		viper.BindEnv("REPO_MAPPING_FILE")
+		viper.BindEnv("SOURCE_HOSTNAME")
+		viper.BindEnv("TARGET_HOSTNAME")
+		viper.BindEnv("USER_SYNC")
+		viper.BindEnv("SKIP_TEAMS")
+		viper.BindEnv("PRUNE")
+		viper.BindEnv("PRUNE_LIMIT")
+		viper.BindEnv("PRUNE_ALLOWLIST")
+		viper.BindEnv("PRUNE_TEAMS")
+		viper.BindEnv("PLAN_FILE")
+		viper.BindEnv("CONCURRENCY")
+		viper.BindEnv("RENAME_RULES_FILE")
+		viper.BindEnv("EXTERNAL_GROUP_MAPPING_FILE")
+		viper.BindEnv("IDP_GROUP_MAPPING_FILE")
+		viper.BindEnv("IDENTITY_MATCH")
+		viper.BindEnv("IDENTITY_REVIEW_FILE")
+		viper.BindEnv("TARGET_ENTERPRISE")
+		viper.BindEnv("EMU_SHORTCODE")
+		viper.BindEnv("SOURCE_EMU_SHORTCODE")
+		viper.BindEnv("EMU_UNRESOLVED_FILE")
+		viper.BindEnv("TEAM_SYNC_GROUPS")
+		viper.BindEnv("SECRET_TEAM_POLICY")
+		bindTeamFilterFlags(cmd)
+
+		// Call createPlan
+		sync.CreatePlan()
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(planCmd)
+
+	// Flags
+	planCmd.Flags().StringP("source-organization", "s", "", "Source Organization to sync teams from")
+	planCmd.MarkFlagRequired("source-organization")
+
+	planCmd.Flags().StringP("target-organization", "t", "", "Target Organization to sync teams to")
+	planCmd.MarkFlagRequired("target-organization")
+
+	planCmd.Flags().StringP("source-token", "a", "", "Source Organization GitHub token. Scopes: read:org, read:user, user:email")
+
+	planCmd.Flags().String("source-app-id", "", "Source GitHub App ID, used instead of --source-token. Needs read access to organization members and administration, and to repository metadata")
+
+	planCmd.Flags().String("source-private-key", "", "Source GitHub App private key, or the path of its PEM file. Ideally set as an env variable: 'GHMT_SOURCE_PRIVATE_KEY'")
+
+	planCmd.Flags().Int64("source-installation-id", 0, "Source GitHub App installation ID. Found from --source-organization when not set")
+
+	planCmd.Flags().StringP("target-token", "b", "", "Target Organization GitHub token. Scopes: admin:org")
+	planCmd.MarkFlagRequired("target-token")
+
+	planCmd.Flags().StringP("mapping-file", "m", "", "Mapping file path to use for mapping teams members handles")
+
+	planCmd.Flags().String("mapping-format", "", "Format of the mapping file: csv (source,target columns), gei (mannequin CSV of gh gei generate-mannequin-csv), json or yaml. Detected from the file extension and header when not set")
+
+	planCmd.Flags().String("team-mapping-file", "", "Mapping file renaming source teams, from owner/team-name or owner/team-slug to the new team name. Columns: source,target, or a JSON or YAML file")
+
+	planCmd.Flags().String("repo-mapping-file", "", "Mapping file renaming source repositories, from owner/repository to the repository name in the target organization or to owner/name. Columns: source,target, or a JSON or YAML file")
+
+	planCmd.Flags().StringP("source-hostname", "u", "", "GitHub Enterprise Server or GHE.com source hostname (optional) Ex. https://github.example.com or acme.ghe.com")
+
+	planCmd.Flags().String("target-hostname", "", "GitHub Enterprise Server or GHE.com target hostname (optional) Ex. https://github.example.com or acme.ghe.com")
+
+	planCmd.Flags().StringP("user-sync", "z", "all", "User sync mode. One of: all, disable")
+
+	planCmd.Flags().BoolP("skip-teams", "k", false, "Skips adding members and repos to teams that already exist to save on API requests (default \"false\")")
+
+	planCmd.Flags().StringP("output", "o", "plan.json", "File path to write the plan to")
+
+	planCmd.Flags().Bool("prune", false, "Removes members and repository access from existing target teams that no longer exist in the source (default \"false\")")
+
+	planCmd.Flags().Bool("prune-teams", false, "When pruning, also deletes target teams that do not exist in the source (default \"false\")")
+
+	planCmd.Flags().Int("prune-limit", 100, "Maximum number of objects prune may remove in a single run")
+
+	planCmd.Flags().String("prune-allowlist", "", "File of objects prune must never remove, one per line. Ex. team:slug, member:slug/login, repository:slug/repo")
+
+	planCmd.Flags().IntP("concurrency", "w", 1, "Number of teams to process in parallel. Parent teams are always finished before their children")
+
+	planCmd.Flags().String("rename-rules", "", "CSV file of ordered regex rewrites for team names, slugs, descriptions and repository names. Columns: field,pattern,replacement")
+
+	planCmd.Flags().String("external-group-mapping-file", "", "CSV file connecting teams to external IdP groups of an Enterprise Managed Users target organization. Columns: team,group")
+
+	planCmd.Flags().String("idp-group-mapping-file", "", "CSV file translating source IdP group IDs to the target IdP tenant. Columns: source_group_id,target_group_id")
+
+	planCmd.Flags().String("identity-match", "", "Comma separated ways to find the target user of each member, tried in order: email (organization verified domain emails), saml (SAML NameID). Members without a single match are not added")
+
+	planCmd.Flags().String("identity-review-file", "gh-migrate-teams-identity-review.csv", "CSV file listing the members --identity-match found no single target user for")
+
+	planCmd.Flags().String("target-enterprise", "", "Target enterprise slug to read SAML identities from when SAML is configured for the enterprise, such as with Enterprise Managed Users")
+
+	planCmd.Flags().String("emu-shortcode", "", "Enterprise shortcode of the target Enterprise Managed Users enterprise, target logins of members that are not in the mapping file are derived as <normalized login>_<shortcode>")
+
+	planCmd.Flags().String("source-emu-shortcode", "", "Enterprise shortcode of the source Enterprise Managed Users enterprise, removed from source logins before target logins are derived with --emu-shortcode")
+
+	planCmd.Flags().String("emu-unresolved-file", "gh-migrate-teams-emu-unresolved.csv", "CSV file listing the members whose login derived with --emu-shortcode is not a member of the target organization")
+
+	planCmd.Flags().Bool("team-sync-groups", false, "Reads the team synchronization IdP groups connected to each source team, which needs a source token of an organization owner (default \"false\")")
+
+	addTeamFilterFlags(planCmd)
+
+	planCmd.Flags().String("secret-team-policy", "closed", "How to handle secret teams with a parent or child teams, which GitHub does not allow. One of: closed, drop-parent, fail")
+}
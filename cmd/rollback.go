@@ -0,0 +1,63 @@
+/*
+Copyright © 2024 NAME HERE <EMAIL ADDRESS>
+*/
+package cmd
+
+import (
+	"os"
+
+	"github.com/mona-actions/gh-migrate-teams/pkg/sync"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+// rollbackCmd represents the rollback command
+var rollbackCmd = &cobra.Command{
+	Use:   "rollback",
+	Short: "Undoes the writes a sync run made to the target organization",
+	Long: `Undoes the writes a sync run made to the target organization using the journal.
+
+	Writes are undone in reverse order: teams created by the run are deleted and access added to existing teams is revoked.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		// Get parameters
+		runId := cmd.Flag("run").Value.String()
+		journalFile := cmd.Flag("journal-file").Value.String()
+		targetToken := cmd.Flag("target-token").Value.String()
+		targetHostname := cmd.Flag("target-hostname").Value.String()
+		dryRun := cmd.Flag("dry-run").Value.String()
+
+		// Set ENV variables
+		os.Setenv("GHMT_RUN_ID", runId)
+		os.Setenv("GHMT_JOURNAL_FILE", journalFile)
+		os.Setenv("GHMT_TARGET_TOKEN", targetToken)
+		os.Setenv("GHMT_TARGET_HOSTNAME", targetHostname)
+		os.Setenv("GHMT_DRY_RUN", dryRun)
+
+		// Bind ENV variables in Viper
+		viper.BindEnv("RUN_ID")
+		viper.BindEnv("JOURNAL_FILE")
+		viper.BindEnv("TARGET_TOKEN")
+		viper.BindEnv("TARGET_HOSTNAME")
+		viper.BindEnv("DRY_RUN")
+
+		// Call rollback
+		sync.Rollback()
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(rollbackCmd)
+
+	// Flags
+	rollbackCmd.Flags().StringP("run", "r", "", "ID of the run to roll back, printed at the start and end of each run")
+	rollbackCmd.MarkFlagRequired("run")
+
+	rollbackCmd.Flags().StringP("journal-file", "j", "gh-migrate-teams-journal.jsonl", "Journal file the run was recorded in")
+
+	rollbackCmd.Flags().StringP("target-token", "b", "", "Target Organization GitHub token. Scopes: admin:org")
+	rollbackCmd.MarkFlagRequired("target-token")
+
+	rollbackCmd.Flags().String("target-hostname", "", "GitHub Enterprise Server or GHE.com target hostname (optional) Ex. https://github.example.com or acme.ghe.com")
+
+	rollbackCmd.Flags().BoolP("dry-run", "d", false, "Lists the writes that would be undone without changing anything (default \"false\")")
+}
@@ -7,59 +7,32 @@ import (
 	"net/http"
 	"strconv"
 	"strings"
+	"sync"
 	"time"
 
 	"github.com/gofri/go-github-ratelimit/github_ratelimit"
 	"github.com/google/go-github/v62/github"
-	"github.com/jferrl/go-githubauth"
+	"github.com/mona-actions/gh-migrate-teams/internal/journal"
+	"github.com/mona-actions/gh-migrate-teams/internal/report"
 	"github.com/shurcooL/githubv4"
 	"github.com/spf13/viper"
 	"golang.org/x/oauth2"
 )
 
+// newHTTPClient returns an HTTP client authenticated to the target with a token or a GitHub App
+// installation, whose token is refreshed automatically
 func newHTTPClient() *http.Client {
-
-	token := viper.GetString("TARGET_TOKEN")
-	appId := viper.GetString("TARGET_APP_ID")
-	privateKey := []byte(viper.GetString("TARGET_PRIVATE_KEY"))
-	installationId := viper.GetInt64("TARGET_INSTALLATION_ID")
-
-	// check that Target token or GitHub App values are set
-	if token == "" && (appId == "" || len(privateKey) == 0 || installationId == 0) {
-		log.Fatalf(
-			"Please provide a target token or a target GitHub App ID and private key.")
-	}
-
-	if appId != "" && len(privateKey) != 0 && installationId != 0 {
-		// GitHub App authentication
-
-		appIdInt, err := strconv.ParseInt(appId, 10, 64)
-		if err != nil {
-			log.Fatalf("Error converting app ID to int64: %v", err)
-		}
-		appToken, err := githubauth.NewApplicationTokenSource(appIdInt, privateKey)
-		if err != nil {
-			log.Fatalf("Error creating app token: %v", err)
-		}
-
-		installationToken := githubauth.NewInstallationTokenSource(installationId, appToken)
-
-		// Create HTTP client with automatic token refresh
-		httpClient := oauth2.NewClient(context.Background(), installationToken)
-
-		return httpClient
-
-	}
-	// Personal access token authentication
-	src := oauth2.StaticTokenSource(&oauth2.Token{AccessToken: token})
-	return oauth2.NewClient(context.Background(), src)
-
+	return oauth2.NewClient(context.Background(), targetTokenSource())
 }
 
 type RateLimitAwareGraphQLClient struct {
 	client *githubv4.Client
 }
 
+func (c *RateLimitAwareGraphQLClient) Mutate(ctx context.Context, m interface{}, input githubv4.Input, variables map[string]interface{}) error {
+	return c.client.Mutate(ctx, m, input, variables)
+}
+
 func (c *RateLimitAwareGraphQLClient) Query(ctx context.Context, q interface{}, variables map[string]interface{}) error {
 	var rateLimitQuery struct {
 		RateLimit struct {
@@ -95,78 +68,126 @@ func (c *RateLimitAwareGraphQLClient) Query(ctx context.Context, q interface{},
 	}
 }
 
-func newGHGraphqlClient(token string) *RateLimitAwareGraphQLClient {
-	hostname := viper.GetString("SOURCE_HOSTNAME")
-	var baseClient *githubv4.Client
+// newGHGraphqlClient returns a GraphQL client for the source, authenticated with a token or a
+// GitHub App installation
+func newGHGraphqlClient() *RateLimitAwareGraphQLClient {
+	httpClient := oauth2.NewClient(context.Background(), sourceTokenSource())
+	return newGraphqlClient(httpClient, viper.GetString("SOURCE_HOSTNAME"))
+}
+
+// newTargetGHGraphqlClient returns a GraphQL client for the target, used for team settings
+// that the REST API does not expose
+func newTargetGHGraphqlClient() *RateLimitAwareGraphQLClient {
+	return newGraphqlClient(newHTTPClient(), viper.GetString("TARGET_HOSTNAME"))
+}
 
-	src := oauth2.StaticTokenSource(&oauth2.Token{AccessToken: token})
-	httpClient := oauth2.NewClient(context.Background(), src)
+// newGraphqlClient returns a rate limit aware GraphQL client of GitHub.com, or of a GitHub
+// Enterprise Server or GHE.com hostname
+func newGraphqlClient(httpClient *http.Client, hostname string) *RateLimitAwareGraphQLClient {
 	rateLimiter, err := github_ratelimit.NewRateLimitWaiterClient(httpClient.Transport)
 
 	if err != nil {
 		panic(err)
 	}
 
-	// Trim any trailing slashes from the hostname
-	hostname = strings.TrimSuffix(hostname, "/")
-
-	// If hostname is received, create a new client with the hostname
-	if hostname != "" {
-		hostname = strings.TrimSuffix(hostname, "/")
-		if !strings.HasPrefix(hostname, "https://") {
-			hostname = "https://" + hostname
+	if url := graphqlURL(hostname); url != "" {
+		return &RateLimitAwareGraphQLClient{
+			client: githubv4.NewEnterpriseClient(url, rateLimiter),
 		}
-		baseClient = githubv4.NewEnterpriseClient(hostname+"/api/graphql", rateLimiter)
-	} else {
-		baseClient = githubv4.NewClient(rateLimiter)
 	}
 
 	return &RateLimitAwareGraphQLClient{
-		client: baseClient,
+		client: githubv4.NewClient(rateLimiter),
 	}
 }
 
+var (
+	restClient     *github.Client
+	restClientOnce sync.Once
+
+	writeMu   sync.Mutex
+	nextWrite time.Time
+)
+
+// newGHRestClient returns the target REST client. A single client is shared so that
+// concurrent workers wait together when GitHub returns a secondary rate limit.
 func newGHRestClient() *github.Client {
-	httpClient := newHTTPClient()
-	rateLimiter, err := github_ratelimit.NewRateLimitWaiterClient(httpClient.Transport)
+	restClientOnce.Do(func() {
+		httpClient := newHTTPClient()
+		rateLimiter, err := github_ratelimit.NewRateLimitWaiterClient(httpClient.Transport)
+
+		if err != nil {
+			panic(err)
+		}
+
+		restClient = newRestClient(rateLimiter, viper.GetString("TARGET_HOSTNAME"))
+	})
+	return restClient
+}
 
+// record adds a write made to the target organization to the journal
+func record(entry journal.Entry, resp *github.Response, err error) {
+	entry.Organization = viper.GetString("TARGET_ORGANIZATION")
+	if resp != nil {
+		entry.Status = resp.StatusCode
+	}
 	if err != nil {
-		panic(err)
+		entry.Error = err.Error()
+	}
+
+	if err := journal.Record(entry); err != nil {
+		log.Println("Unable to write to journal - ", err)
+	}
+}
+
+// waitForWrite spaces out write requests to the target by WRITE_INTERVAL across all
+// workers, as GitHub recommends pausing between mutating requests to avoid secondary rate limits
+func waitForWrite() {
+	interval := viper.GetDuration("WRITE_INTERVAL")
+	if interval <= 0 {
+		return
+	}
+
+	writeMu.Lock()
+	wait := time.Until(nextWrite)
+	if wait < 0 {
+		wait = 0
 	}
+	nextWrite = time.Now().Add(wait + interval)
+	writeMu.Unlock()
 
-	return github.NewClient(rateLimiter)
+	time.Sleep(wait)
 }
 
+// newSourceGHRestClient returns a REST client for the source, authenticated with a token or a
+// GitHub App installation
 func newSourceGHRestClient() *github.Client {
-	token := viper.GetString("SOURCE_TOKEN")
-	hostname := viper.GetString("SOURCE_HOSTNAME")
-	ctx := context.Background()
-	ts := oauth2.StaticTokenSource(&oauth2.Token{AccessToken: token})
-	tc := oauth2.NewClient(ctx, ts)
+	tc := oauth2.NewClient(context.Background(), sourceTokenSource())
 	rateLimiter, err := github_ratelimit.NewRateLimitWaiterClient(tc.Transport)
 
 	if err != nil {
 		panic(err)
 	}
 
-	if hostname != "" {
-		hostname = strings.TrimSuffix(hostname, "/")
-		if !strings.HasPrefix(hostname, "https://") {
-			hostname = "https://" + hostname
-		}
-		baseURL := fmt.Sprintf("%s/api/v3/", hostname)
-		client, err := github.NewClient(rateLimiter).WithEnterpriseURLs(baseURL, baseURL)
+	return newRestClient(rateLimiter, viper.GetString("SOURCE_HOSTNAME"))
+}
+
+// newRestClient returns a REST client of GitHub.com, or of a GitHub Enterprise Server or GHE.com
+// hostname
+func newRestClient(httpClient *http.Client, hostname string) *github.Client {
+	client := github.NewClient(httpClient)
+	if baseURL := restBaseURL(hostname); baseURL != "" {
+		enterpriseClient, err := client.WithEnterpriseURLs(baseURL, baseURL)
 		if err != nil {
 			panic(err)
 		}
-		return client
+		return enterpriseClient
 	}
-
-	return github.NewClient(rateLimiter)
+	return client
 }
 
 func GetSourceOrganizationTeams() []map[string]string {
-	client := newGHGraphqlClient(viper.GetString("SOURCE_TOKEN"))
+	client := newGHGraphqlClient()
 
 	var query struct {
 		Organization struct {
@@ -186,6 +207,7 @@ func GetSourceOrganizationTeams() []map[string]string {
 							Id   string
 							Slug string
 						}
+						teamSettings
 					}
 				}
 			} `graphql:"teams(first: $first, after: $after)"`
@@ -206,15 +228,16 @@ func GetSourceOrganizationTeams() []map[string]string {
 		}
 
 		for _, team := range query.Organization.Teams.Edges {
-			teams = append(teams, map[string]string{
-				"Id":             team.Node.Id,
-				"Name":           team.Node.Name,
-				"Slug":           team.Node.Slug,
-				"Description":    team.Node.Description,
-				"Privacy":        team.Node.Privacy,
-				"ParentTeamId":   team.Node.ParentTeam.Id,
-				"ParentTeamName": team.Node.ParentTeam.Slug,
-			})
+			data := team.Node.teamSettings.values()
+			data["Id"] = team.Node.Id
+			data["Name"] = team.Node.Name
+			data["Slug"] = team.Node.Slug
+			data["Description"] = team.Node.Description
+			data["Privacy"] = team.Node.Privacy
+			data["ParentTeamId"] = team.Node.ParentTeam.Id
+			data["ParentTeamName"] = team.Node.ParentTeam.Slug
+			addReviewExcludedMembers(client, viper.GetString("SOURCE_ORGANIZATION"), team.Node.Slug, data)
+			teams = append(teams, data)
 		}
 
 		if !query.Organization.Teams.PageInfo.HasNextPage {
@@ -228,7 +251,7 @@ func GetSourceOrganizationTeams() []map[string]string {
 }
 
 func GetTeamMemberships(team string) []map[string]string {
-	client := newGHGraphqlClient(viper.GetString("source_token"))
+	client := newGHGraphqlClient()
 
 	var query struct {
 		Organization struct {
@@ -278,58 +301,35 @@ func GetTeamMemberships(team string) []map[string]string {
 	return members
 }
 
+// GetTeamRepositories returns the repositories of a source team. The REST API is used as it
+// returns the name of custom repository roles, which GraphQL reports as their base role.
 func GetTeamRepositories(team string) []map[string]string {
-	client := newGHGraphqlClient(viper.GetString("source_token"))
-
-	var query struct {
-		Organization struct {
-			Team struct {
-				Repositories struct {
-					PageInfo struct {
-						EndCursor   githubv4.String
-						HasNextPage bool
-					}
-					Edges []struct {
-						Permission string
-						Node       struct {
-							Name string
-						}
-					}
-				} `graphql:"repositories(first: $first, after: $after)"`
-			} `graphql:"team(slug: $slug)"`
-		} `graphql:"organization(login: $login)"`
-	}
-
-	variables := map[string]interface{}{
-		"login": githubv4.String(viper.Get("SOURCE_ORGANIZATION").(string)),
-		"slug":  githubv4.String(team),
-		"first": githubv4.Int(100),
-		"after": (*githubv4.String)(nil),
-	}
+	client := newSourceGHRestClient()
+	ctx := context.WithValue(context.Background(), github.SleepUntilPrimaryRateLimitResetWhenRateLimited, true)
 
 	var repositories = []map[string]string{}
+	opts := &github.ListOptions{PerPage: 100}
 	for {
-		err := client.Query(context.Background(), &query, variables)
+		repos, resp, err := client.Teams.ListTeamReposBySlug(ctx, viper.Get("SOURCE_ORGANIZATION").(string), team, opts)
 		if err != nil {
 			panic(err)
 		}
 
-		for _, repo := range query.Organization.Team.Repositories.Edges {
-			repositories = append(repositories, map[string]string{"Name": repo.Node.Name, "Permission": repo.Permission})
+		for _, repo := range repos {
+			repositories = append(repositories, map[string]string{"Name": repo.GetName(), "Permission": repositoryPermission(repo)})
 		}
 
-		if !query.Organization.Team.Repositories.PageInfo.HasNextPage {
+		if resp.NextPage == 0 {
 			break
 		}
-
-		variables["after"] = githubv4.NewString(query.Organization.Team.Repositories.PageInfo.EndCursor)
+		opts.Page = resp.NextPage
 	}
 
 	return repositories
 }
 
 func GetSourceOrganizationRepositories() []map[string]string {
-	client := newGHGraphqlClient(viper.GetString("source_token"))
+	client := newGHGraphqlClient()
 
 	var query struct {
 		Organization struct {
@@ -375,7 +375,7 @@ func GetSourceOrganizationRepositories() []map[string]string {
 }
 
 func GetRepositoryCollaborators(repository string) []map[string]string {
-	client := newGHGraphqlClient(viper.GetString("source_token"))
+	client := newGHGraphqlClient()
 
 	var query struct {
 		Repository struct {
@@ -423,21 +423,30 @@ func GetRepositoryCollaborators(repository string) []map[string]string {
 	return collaborators
 }
 
-func CreateTeam(name string, description string, privacy string, parentTeamName string) error {
+func CreateTeam(name string, description string, privacy string, parentTeamName string, notificationSetting string) error {
 	client := newGHRestClient()
+	waitForWrite()
 
 	t := github.NewTeam{Name: name, Description: &description, Privacy: &privacy}
+	if notificationSetting != "" {
+		t.NotificationSetting = &notificationSetting
+	}
 	if parentTeamName != "" {
 		parentTeamID, err := GetTeamId(parentTeamName)
 		if err != nil {
-			fmt.Println("Team ID Not found", err)
+			fmt.Println("Parent team", parentTeamName, "not found, creating team", name, "without a parent until it exists", err)
 		} else {
 			t.ParentTeamID = &parentTeamID
 		}
 	}
 
 	ctx := context.WithValue(context.Background(), github.SleepUntilPrimaryRateLimitResetWhenRateLimited, true)
-	_, _, err := client.Teams.CreateTeam(ctx, viper.Get("TARGET_ORGANIZATION").(string), t)
+	created, resp, err := client.Teams.CreateTeam(ctx, viper.Get("TARGET_ORGANIZATION").(string), t)
+	slug := name
+	if created != nil {
+		slug = created.GetSlug()
+	}
+	record(journal.Entry{Action: journal.TeamCreated, Team: slug}, resp, err)
 
 	if err != nil {
 		if strings.Contains(err.Error(), "Name must be unique for this org") {
@@ -451,36 +460,117 @@ func CreateTeam(name string, description string, privacy string, parentTeamName
 	return nil
 }
 
-func AddTeamRepository(slug string, repo string, permission string) {
+// AddTeamRepository grants a team access to a repository, which is a repository of the target
+// organization or owner/name. GitHub only grants teams access to repositories of their own
+// organization, so repositories of another owner are refused without calling GitHub.
+func AddTeamRepository(slug string, repo string, permission string) error {
+	if err := CrossOwnerError(slug, repo); err != nil {
+		return err
+	}
 	client := newGHRestClient()
+	owner, name := repositoryOwner(repo)
 
+	// Only writes that change the target are journaled, so that rollback leaves access the team
+	// had before the run alone
+	previous, err := teamRepositoryPermission(owner, slug, name)
+	if err != nil {
+		log.Println("Unable to read access of team", slug, "to", repo, "-", err)
+	} else if strings.EqualFold(previous, permission) {
+		fmt.Println("Team already has access to repository: ", slug, repo, permission)
+		return nil
+	}
+
+	waitForWrite()
 	fmt.Println("Adding repository to team: ", slug, repo, permission)
 
 	ctx := context.WithValue(context.Background(), github.SleepUntilPrimaryRateLimitResetWhenRateLimited, true)
-	_, err := client.Teams.AddTeamRepoBySlug(ctx, viper.Get("TARGET_ORGANIZATION").(string), slug, viper.Get("TARGET_ORGANIZATION").(string), repo, &github.TeamAddTeamRepoOptions{Permission: permission})
+	resp, err := client.Teams.AddTeamRepoBySlug(ctx, owner, slug, owner, name, &github.TeamAddTeamRepoOptions{Permission: permission})
+	entry := journal.Entry{Action: journal.RepositoryGranted, Team: slug, Repository: repo, Permission: permission}
+	if previous != "" {
+		entry.Action, entry.Previous = journal.RepositoryChanged, previous
+	}
+	record(entry, resp, err)
+	return err
+}
+
+// teamRepositoryPermission returns the permission of a team on a repository of owner, or an empty
+// permission when the team has no access to it
+func teamRepositoryPermission(owner string, slug string, name string) (string, error) {
+	client := newGHRestClient()
+	ctx := context.WithValue(context.Background(), github.SleepUntilPrimaryRateLimitResetWhenRateLimited, true)
 
+	repository, resp, err := client.Teams.IsTeamRepoBySlug(ctx, owner, slug, owner, name)
+	if resp != nil && resp.StatusCode == http.StatusNotFound {
+		return "", nil
+	}
 	if err != nil {
-		if strings.Contains(err.Error(), "422 Validation Failed") {
-			fmt.Println("Error adding repository to team: ", slug, repo, permission)
-		} else if strings.Contains(err.Error(), "404 Not Found") {
-			fmt.Println("Error adding repository to team, repository not found: ", slug, repo, permission)
-		} else {
-			fmt.Println("error adding repository", repo, " to team: ", slug, "with permissions:", permission, "Unknown error", err, err.Error())
-		}
+		return "", err
+	}
+	return repositoryPermission(repository), nil
+}
+
+// repositoryOwner splits owner/name, a repository without an owner belongs to the target organization
+func repositoryOwner(repo string) (string, string) {
+	if owner, name, found := strings.Cut(repo, "/"); found {
+		return owner, name
+	}
+	return viper.GetString("TARGET_ORGANIZATION"), repo
+}
+
+// CrossOwnerError returns why a team of the target organization cannot be granted access to a
+// repository of another owner, nil for repositories of the target organization. Teams of another
+// organization are never written to implicitly.
+func CrossOwnerError(slug string, repo string) error {
+	owner, name := repositoryOwner(repo)
+	if strings.EqualFold(owner, viper.GetString("TARGET_ORGANIZATION")) {
+		return nil
 	}
+	return fmt.Errorf("%w: %s/%s is not owned by %s, GitHub only grants teams access to repositories of their own organization, grant team %s access in %s once it is synced there",
+		report.ErrCrossOwner, owner, name, viper.GetString("TARGET_ORGANIZATION"), slug, owner)
 }
 
-func AddTeamMember(slug string, member string, role string) {
+func AddTeamMember(slug string, member string, role string) error {
 	client := newGHRestClient()
 
 	role = strings.ToLower(role) // lowercase to match github api
+
+	// Only writes that change the target are journaled, so that rollback leaves members the team
+	// had before the run alone
+	previous, err := teamMemberRole(slug, member)
+	if err != nil {
+		log.Println("Unable to read membership of", member, "in team", slug, "-", err)
+	} else if previous == role {
+		fmt.Println("Member already in team: ", slug, member, role)
+		return nil
+	}
+
+	waitForWrite()
 	fmt.Println("Adding member to team: ", slug, member, role)
 
 	ctx := context.WithValue(context.Background(), github.SleepUntilPrimaryRateLimitResetWhenRateLimited, true)
-	_, _, err := client.Teams.AddTeamMembershipBySlug(ctx, viper.Get("TARGET_ORGANIZATION").(string), slug, member, &github.TeamAddTeamMembershipOptions{Role: role})
+	_, resp, err := client.Teams.AddTeamMembershipBySlug(ctx, viper.Get("TARGET_ORGANIZATION").(string), slug, member, &github.TeamAddTeamMembershipOptions{Role: role})
+	entry := journal.Entry{Action: journal.MemberAdded, Team: slug, Member: member, Role: role}
+	if previous != "" {
+		entry.Action, entry.Previous = journal.MemberRoleChanged, previous
+	}
+	record(entry, resp, err)
+	return err
+}
+
+// teamMemberRole returns the role of a member of a target team, or an empty role when they are
+// not a member
+func teamMemberRole(slug string, member string) (string, error) {
+	client := newGHRestClient()
+	ctx := context.WithValue(context.Background(), github.SleepUntilPrimaryRateLimitResetWhenRateLimited, true)
+
+	membership, resp, err := client.Teams.GetTeamMembershipBySlug(ctx, viper.GetString("TARGET_ORGANIZATION"), slug, member)
+	if resp != nil && resp.StatusCode == http.StatusNotFound {
+		return "", nil
+	}
 	if err != nil {
-		fmt.Println("Error adding member ", member, " to team: ", slug, err)
+		return "", err
 	}
+	return strings.ToLower(membership.GetRole()), nil
 }
 
 func GetTeamId(TeamName string) (int64, error) {
@@ -525,12 +615,912 @@ func GetAuthenticatedUser() (*github.User, error) {
 }
 
 func RemoveTeamMember(slug string, member string) error {
+	client := newGHRestClient()
+	waitForWrite()
+	ctx := context.WithValue(context.Background(), github.SleepUntilPrimaryRateLimitResetWhenRateLimited, true)
+
+	resp, err := client.Teams.RemoveTeamMembershipBySlug(ctx, viper.Get("TARGET_ORGANIZATION").(string), slug, member)
+	record(journal.Entry{Action: journal.MemberRemoved, Team: slug, Member: member}, resp, err)
+	if err != nil {
+		return err
+	}
+	return nil
+}
+
+func GetTargetTeam(slug string) (map[string]string, error) {
+	client := newGHRestClient()
+	ctx := context.WithValue(context.Background(), github.SleepUntilPrimaryRateLimitResetWhenRateLimited, true)
+
+	team, resp, err := client.Teams.GetTeamBySlug(ctx, viper.Get("TARGET_ORGANIZATION").(string), slug)
+	if err != nil {
+		if resp != nil && resp.StatusCode == http.StatusNotFound {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	parentTeamName := ""
+	if team.Parent != nil {
+		parentTeamName = team.Parent.GetSlug()
+	}
+
+	return map[string]string{
+		"Id":             strconv.FormatInt(team.GetID(), 10),
+		"Name":           team.GetName(),
+		"Slug":           team.GetSlug(),
+		"Description":    team.GetDescription(),
+		"Privacy":        team.GetPrivacy(),
+		"ParentTeamName": parentTeamName,
+	}, nil
+}
+
+func GetTargetTeamMembers(slug string) ([]map[string]string, error) {
+	client := newGHRestClient()
+	ctx := context.WithValue(context.Background(), github.SleepUntilPrimaryRateLimitResetWhenRateLimited, true)
+
+	var members = []map[string]string{}
+	// The REST API does not return the role of each member, so list each role separately
+	for _, role := range []string{"maintainer", "member"} {
+		opts := &github.TeamListTeamMembersOptions{Role: role, ListOptions: github.ListOptions{PerPage: 100}}
+		for {
+			users, resp, err := client.Teams.ListTeamMembersBySlug(ctx, viper.Get("TARGET_ORGANIZATION").(string), slug, opts)
+			if err != nil {
+				return nil, err
+			}
+
+			for _, user := range users {
+				members = append(members, map[string]string{"Login": user.GetLogin(), "Role": role})
+			}
+
+			if resp.NextPage == 0 {
+				break
+			}
+			opts.Page = resp.NextPage
+		}
+	}
+
+	return members, nil
+}
+
+// GetTargetOrganizationMembers returns the logins of the members of the target organization
+func GetTargetOrganizationMembers() ([]string, error) {
+	client := newGHRestClient()
+	ctx := context.WithValue(context.Background(), github.SleepUntilPrimaryRateLimitResetWhenRateLimited, true)
+
+	var logins = []string{}
+	opts := &github.ListMembersOptions{ListOptions: github.ListOptions{PerPage: 100}}
+	for {
+		users, resp, err := client.Organizations.ListMembers(ctx, viper.GetString("TARGET_ORGANIZATION"), opts)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, user := range users {
+			logins = append(logins, user.GetLogin())
+		}
+
+		if resp.NextPage == 0 {
+			break
+		}
+		opts.Page = resp.NextPage
+	}
+
+	return logins, nil
+}
+
+func GetTargetTeamRepositories(slug string) ([]map[string]string, error) {
 	client := newGHRestClient()
 	ctx := context.WithValue(context.Background(), github.SleepUntilPrimaryRateLimitResetWhenRateLimited, true)
 
-	_, err := client.Teams.RemoveTeamMembershipBySlug(ctx, viper.Get("TARGET_ORGANIZATION").(string), slug, member)
+	var repositories = []map[string]string{}
+	opts := &github.ListOptions{PerPage: 100}
+	for {
+		repos, resp, err := client.Teams.ListTeamReposBySlug(ctx, viper.Get("TARGET_ORGANIZATION").(string), slug, opts)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, repo := range repos {
+			repositories = append(repositories, map[string]string{"Name": repo.GetName(), "Permission": repositoryPermission(repo)})
+		}
+
+		if resp.NextPage == 0 {
+			break
+		}
+		opts.Page = resp.NextPage
+	}
+
+	return repositories, nil
+}
+
+// repositoryPermission returns the highest permission a team has on a repository
+// using the same values accepted by AddTeamRepository
+func repositoryPermission(repo *github.Repository) string {
+	switch repo.GetRoleName() {
+	case "read":
+		return "pull"
+	case "write":
+		return "push"
+	case "":
+	default:
+		return repo.GetRoleName()
+	}
+
+	for _, permission := range []string{"admin", "maintain", "push", "triage", "pull"} {
+		if repo.Permissions[permission] {
+			return permission
+		}
+	}
+	return ""
+}
+
+func UpdateTeam(slug string, name string, description string, privacy string, parentTeamName string, notificationSetting string) error {
+	client := newGHRestClient()
+	waitForWrite()
+
+	t := github.NewTeam{Name: name, Description: &description, Privacy: &privacy}
+	if notificationSetting != "" {
+		t.NotificationSetting = &notificationSetting
+	}
+	removeParent := parentTeamName == ""
+	if parentTeamName != "" {
+		parentTeamID, err := GetTeamId(parentTeamName)
+		if err != nil {
+			return err
+		}
+		t.ParentTeamID = &parentTeamID
+	}
+
+	ctx := context.WithValue(context.Background(), github.SleepUntilPrimaryRateLimitResetWhenRateLimited, true)
+	_, resp, err := client.Teams.EditTeamBySlug(ctx, viper.Get("TARGET_ORGANIZATION").(string), slug, t, removeParent)
+	record(journal.Entry{Action: journal.TeamUpdated, Team: slug}, resp, err)
 	if err != nil {
 		return err
 	}
 	return nil
 }
+
+// teamSettings are the team settings that are only available through GraphQL
+type teamSettings struct {
+	NotificationSetting                string
+	ReviewRequestDelegationEnabled     bool
+	ReviewRequestDelegationAlgorithm   string
+	ReviewRequestDelegationMemberCount int
+	ReviewRequestDelegationNotifyTeam  bool
+}
+
+func (s teamSettings) values() map[string]string {
+	return map[string]string{
+		"NotificationSetting":                s.NotificationSetting,
+		"ReviewRequestDelegationEnabled":     strconv.FormatBool(s.ReviewRequestDelegationEnabled),
+		"ReviewRequestDelegationAlgorithm":   s.ReviewRequestDelegationAlgorithm,
+		"ReviewRequestDelegationMemberCount": strconv.Itoa(s.ReviewRequestDelegationMemberCount),
+		"ReviewRequestDelegationNotifyTeam":  strconv.FormatBool(s.ReviewRequestDelegationNotifyTeam),
+	}
+}
+
+// getTeamSettings returns the notification and code review assignment settings of a team,
+// nil is returned when the team does not exist
+func getTeamSettings(client *RateLimitAwareGraphQLClient, organization string, slug string) (map[string]string, error) {
+	var query struct {
+		Organization struct {
+			Team *struct {
+				Id string
+				teamSettings
+			} `graphql:"team(slug: $slug)"`
+		} `graphql:"organization(login: $login)"`
+	}
+
+	variables := map[string]interface{}{
+		"login": githubv4.String(organization),
+		"slug":  githubv4.String(slug),
+	}
+
+	if err := client.Query(context.Background(), &query, variables); err != nil {
+		return nil, err
+	}
+	if query.Organization.Team == nil {
+		return nil, nil
+	}
+
+	settings := query.Organization.Team.teamSettings.values()
+	settings["Id"] = query.Organization.Team.Id
+	addReviewExcludedMembers(client, organization, slug, settings)
+	return settings, nil
+}
+
+// addReviewExcludedMembers adds the members excluded from code review assignment to the settings
+// of a team that delegates review requests
+func addReviewExcludedMembers(client *RateLimitAwareGraphQLClient, organization string, slug string, settings map[string]string) {
+	if settings["ReviewRequestDelegationEnabled"] != "true" {
+		return
+	}
+	// Servers that do not expose the excluded members leave them to the teams CSV
+	excluded, err := getTeamReviewExcludedMembers(client, organization, slug)
+	if err != nil {
+		log.Println("Unable to read the members excluded from code review assignment of team", slug, "-", err)
+		return
+	}
+	settings["ReviewRequestDelegationExcludedMembers"] = strings.Join(excluded, ";")
+}
+
+// getTeamReviewExcludedMembers returns the logins of the members of a team that are excluded
+// from code review assignment
+func getTeamReviewExcludedMembers(client *RateLimitAwareGraphQLClient, organization string, slug string) ([]string, error) {
+	var query struct {
+		Organization struct {
+			Team struct {
+				ReviewRequestDelegationExcludedMembers struct {
+					PageInfo struct {
+						EndCursor   githubv4.String
+						HasNextPage bool
+					}
+					Nodes []struct {
+						Login string
+					}
+				} `graphql:"reviewRequestDelegationExcludedMembers(first: $first, after: $after)"`
+			} `graphql:"team(slug: $slug)"`
+		} `graphql:"organization(login: $login)"`
+	}
+
+	variables := map[string]interface{}{
+		"login": githubv4.String(organization),
+		"slug":  githubv4.String(slug),
+		"first": githubv4.Int(100),
+		"after": (*githubv4.String)(nil),
+	}
+
+	var logins = []string{}
+	for {
+		if err := client.Query(context.Background(), &query, variables); err != nil {
+			return nil, err
+		}
+
+		for _, member := range query.Organization.Team.ReviewRequestDelegationExcludedMembers.Nodes {
+			logins = append(logins, member.Login)
+		}
+
+		if !query.Organization.Team.ReviewRequestDelegationExcludedMembers.PageInfo.HasNextPage {
+			break
+		}
+		variables["after"] = githubv4.NewString(query.Organization.Team.ReviewRequestDelegationExcludedMembers.PageInfo.EndCursor)
+	}
+
+	return logins, nil
+}
+
+// GetSourceTeamSettings returns the notification and code review assignment settings of a source team
+func GetSourceTeamSettings(slug string) (map[string]string, error) {
+	client := newGHGraphqlClient()
+	return getTeamSettings(client, viper.GetString("SOURCE_ORGANIZATION"), slug)
+}
+
+// GetTargetTeamSettings returns the notification and code review assignment settings of a target team
+func GetTargetTeamSettings(slug string) (map[string]string, error) {
+	return getTeamSettings(newTargetGHGraphqlClient(), viper.GetString("TARGET_ORGANIZATION"), slug)
+}
+
+// UpdateTeamReviewAssignment sets the code review assignment of a target team. The algorithm,
+// member count, notify team and excluded members are only sent when assignment is enabled.
+func UpdateTeamReviewAssignment(slug string, enabled bool, algorithm string, memberCount int, notifyTeam bool, excludedMembers []string) error {
+	settings, err := GetTargetTeamSettings(slug)
+	if err != nil {
+		return err
+	}
+	if settings == nil {
+		return fmt.Errorf("team %s not found in target organization", slug)
+	}
+
+	input := githubv4.UpdateTeamReviewAssignmentInput{
+		ID:      githubv4.ID(settings["Id"]),
+		Enabled: githubv4.Boolean(enabled),
+	}
+	if enabled {
+		if algorithm != "" {
+			a := githubv4.TeamReviewAssignmentAlgorithm(strings.ToUpper(algorithm))
+			input.Algorithm = &a
+		}
+		if memberCount > 0 {
+			input.TeamMemberCount = githubv4.NewInt(githubv4.Int(memberCount))
+		}
+		input.NotifyTeam = githubv4.NewBoolean(githubv4.Boolean(notifyTeam))
+
+		if len(excludedMembers) > 0 {
+			// Members are excluded by their node ID in the target
+			client := newGHRestClient()
+			ids := make([]githubv4.ID, 0, len(excludedMembers))
+			for _, login := range excludedMembers {
+				user, _, err := client.Users.Get(context.Background(), login)
+				if err != nil {
+					return fmt.Errorf("unable to find excluded member %s: %w", login, err)
+				}
+				ids = append(ids, githubv4.ID(user.GetNodeID()))
+			}
+			input.ExcludedTeamMemberIDs = &ids
+		}
+	}
+
+	var mutation struct {
+		UpdateTeamReviewAssignment struct {
+			Team struct {
+				Id string
+			}
+		} `graphql:"updateTeamReviewAssignment(input: $input)"`
+	}
+
+	waitForWrite()
+	err = newTargetGHGraphqlClient().Mutate(context.Background(), &mutation, input, nil)
+	// GraphQL responses carry no status, so the journal records success as 200
+	entry := journal.Entry{Action: journal.TeamUpdated, Team: slug}
+	if err == nil {
+		entry.Status = http.StatusOK
+	}
+	record(entry, nil, err)
+	return err
+}
+
+func GetTargetOrganizationTeams() ([]map[string]string, error) {
+	client := newGHRestClient()
+	ctx := context.WithValue(context.Background(), github.SleepUntilPrimaryRateLimitResetWhenRateLimited, true)
+
+	var teams = []map[string]string{}
+	opts := &github.ListOptions{PerPage: 100}
+	for {
+		page, resp, err := client.Teams.ListTeams(ctx, viper.Get("TARGET_ORGANIZATION").(string), opts)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, team := range page {
+			parentTeamName := ""
+			if team.Parent != nil {
+				parentTeamName = team.Parent.GetSlug()
+			}
+			teams = append(teams, map[string]string{"Name": team.GetName(), "Slug": team.GetSlug(), "ParentTeamName": parentTeamName})
+		}
+
+		if resp.NextPage == 0 {
+			break
+		}
+		opts.Page = resp.NextPage
+	}
+
+	return teams, nil
+}
+
+func RemoveTeamRepository(slug string, repo string) error {
+	if err := CrossOwnerError(slug, repo); err != nil {
+		return err
+	}
+	client := newGHRestClient()
+	waitForWrite()
+	ctx := context.WithValue(context.Background(), github.SleepUntilPrimaryRateLimitResetWhenRateLimited, true)
+
+	owner, name := repositoryOwner(repo)
+	resp, err := client.Teams.RemoveTeamRepoBySlug(ctx, owner, slug, owner, name)
+	record(journal.Entry{Action: journal.RepositoryRevoked, Team: slug, Repository: repo}, resp, err)
+	if err != nil {
+		return err
+	}
+	return nil
+}
+
+func DeleteTeam(slug string) error {
+	client := newGHRestClient()
+	waitForWrite()
+	ctx := context.WithValue(context.Background(), github.SleepUntilPrimaryRateLimitResetWhenRateLimited, true)
+
+	resp, err := client.Teams.DeleteTeamBySlug(ctx, viper.Get("TARGET_ORGANIZATION").(string), slug)
+	record(journal.Entry{Action: journal.TeamDeleted, Team: slug}, resp, err)
+	if err != nil {
+		return err
+	}
+	return nil
+}
+
+func SetTeamParent(slug string, name string, parentTeamName string) error {
+	client := newGHRestClient()
+	waitForWrite()
+
+	parentTeamID, err := GetTeamId(parentTeamName)
+	if err != nil {
+		return err
+	}
+
+	ctx := context.WithValue(context.Background(), github.SleepUntilPrimaryRateLimitResetWhenRateLimited, true)
+	_, resp, err := client.Teams.EditTeamBySlug(ctx, viper.Get("TARGET_ORGANIZATION").(string), slug, github.NewTeam{Name: name, ParentTeamID: &parentTeamID}, false)
+	record(journal.Entry{Action: journal.TeamUpdated, Team: slug}, resp, err)
+	if err != nil {
+		return err
+	}
+	return nil
+}
+
+// GetSourceRepositoryCollaborators returns the users with direct access to a source repository,
+// marking outside collaborators, as access granted through teams is migrated with the teams
+func GetSourceRepositoryCollaborators(owner string, repo string) ([]map[string]string, error) {
+	client := newSourceGHRestClient()
+	ctx := context.WithValue(context.Background(), github.SleepUntilPrimaryRateLimitResetWhenRateLimited, true)
+
+	outside := make(map[string]bool)
+	users, err := listCollaborators(ctx, client, owner, repo, "outside")
+	if err != nil {
+		return nil, err
+	}
+	for _, user := range users {
+		outside[user.GetLogin()] = true
+	}
+
+	users, err = listCollaborators(ctx, client, owner, repo, "direct")
+	if err != nil {
+		return nil, err
+	}
+
+	var collaborators = []map[string]string{}
+	for _, user := range users {
+		collaborators = append(collaborators, map[string]string{
+			"Login":      user.GetLogin(),
+			"Permission": collaboratorPermission(user),
+			"Outside":    strconv.FormatBool(outside[user.GetLogin()]),
+		})
+	}
+
+	return collaborators, nil
+}
+
+// GetTargetRepositoryCollaborators returns the users with direct access to a target repository
+func GetTargetRepositoryCollaborators(repo string) ([]map[string]string, error) {
+	client := newGHRestClient()
+	ctx := context.WithValue(context.Background(), github.SleepUntilPrimaryRateLimitResetWhenRateLimited, true)
+
+	users, err := listCollaborators(ctx, client, viper.Get("TARGET_ORGANIZATION").(string), repo, "direct")
+	if err != nil {
+		return nil, err
+	}
+
+	var collaborators = []map[string]string{}
+	for _, user := range users {
+		collaborators = append(collaborators, map[string]string{"Login": user.GetLogin(), "Permission": collaboratorPermission(user)})
+	}
+
+	return collaborators, nil
+}
+
+func listCollaborators(ctx context.Context, client *github.Client, owner string, repo string, affiliation string) ([]*github.User, error) {
+	var users []*github.User
+	opts := &github.ListCollaboratorsOptions{Affiliation: affiliation, ListOptions: github.ListOptions{PerPage: 100}}
+	for {
+		page, resp, err := client.Repositories.ListCollaborators(ctx, owner, repo, opts)
+		if err != nil {
+			return nil, err
+		}
+		users = append(users, page...)
+
+		if resp.NextPage == 0 {
+			break
+		}
+		opts.Page = resp.NextPage
+	}
+	return users, nil
+}
+
+// collaboratorPermission returns the permission of a collaborator using the same values
+// accepted by AddRepositoryCollaborator
+func collaboratorPermission(user *github.User) string {
+	return repositoryPermission(&github.Repository{RoleName: user.RoleName, Permissions: user.Permissions})
+}
+
+// GetTargetRepositoryInvitations returns the pending invitations to a target repository
+func GetTargetRepositoryInvitations(repo string) ([]map[string]string, error) {
+	client := newGHRestClient()
+	ctx := context.WithValue(context.Background(), github.SleepUntilPrimaryRateLimitResetWhenRateLimited, true)
+
+	var invitations = []map[string]string{}
+	opts := &github.ListOptions{PerPage: 100}
+	for {
+		page, resp, err := client.Repositories.ListInvitations(ctx, viper.Get("TARGET_ORGANIZATION").(string), repo, opts)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, invitation := range page {
+			invitations = append(invitations, map[string]string{
+				"Login":      invitation.GetInvitee().GetLogin(),
+				"Permission": repositoryPermission(&github.Repository{RoleName: invitation.Permissions}),
+				"CreatedAt":  invitation.GetCreatedAt().UTC().Format(time.RFC3339),
+			})
+		}
+
+		if resp.NextPage == 0 {
+			break
+		}
+		opts.Page = resp.NextPage
+	}
+
+	return invitations, nil
+}
+
+// AddRepositoryCollaborator gives a user direct access to a target repository. Users that are
+// not members of the target organization are sent an invitation, in which case invited is true.
+func AddRepositoryCollaborator(repo string, login string, permission string) (bool, error) {
+	client := newGHRestClient()
+	waitForWrite()
+
+	fmt.Println("Adding collaborator to repository: ", repo, login, permission)
+
+	ctx := context.WithValue(context.Background(), github.SleepUntilPrimaryRateLimitResetWhenRateLimited, true)
+	invitation, resp, err := client.Repositories.AddCollaborator(ctx, viper.Get("TARGET_ORGANIZATION").(string), repo, login, &github.RepositoryAddCollaboratorOptions{Permission: permission})
+	invited := invitation != nil && invitation.ID != nil
+	action := journal.CollaboratorAdded
+	if invited {
+		action = journal.CollaboratorInvited
+	}
+	record(journal.Entry{Action: action, Member: login, Repository: repo, Permission: permission}, resp, err)
+	return invited, err
+}
+
+// ChangeRepositoryCollaborator changes the permission of a direct collaborator of a target
+// repository, journaling the permission it replaces
+func ChangeRepositoryCollaborator(repo string, login string, permission string, previous string) error {
+	client := newGHRestClient()
+	waitForWrite()
+
+	fmt.Println("Changing collaborator permission on repository: ", repo, login, previous, "to", permission)
+
+	ctx := context.WithValue(context.Background(), github.SleepUntilPrimaryRateLimitResetWhenRateLimited, true)
+	_, resp, err := client.Repositories.AddCollaborator(ctx, viper.Get("TARGET_ORGANIZATION").(string), repo, login, &github.RepositoryAddCollaboratorOptions{Permission: permission})
+	record(journal.Entry{Action: journal.CollaboratorChanged, Member: login, Repository: repo, Permission: permission, Previous: previous}, resp, err)
+	return err
+}
+
+// RemoveRepositoryCollaborator removes a user's direct access to a target repository,
+// which also cancels a pending invitation
+func RemoveRepositoryCollaborator(repo string, login string) error {
+	client := newGHRestClient()
+	waitForWrite()
+	ctx := context.WithValue(context.Background(), github.SleepUntilPrimaryRateLimitResetWhenRateLimited, true)
+
+	resp, err := client.Repositories.RemoveCollaborator(ctx, viper.Get("TARGET_ORGANIZATION").(string), repo, login)
+	record(journal.Entry{Action: journal.CollaboratorRemoved, Member: login, Repository: repo}, resp, err)
+	return err
+}
+
+// GetSourceCustomRepositoryRoles returns the custom repository roles defined in the source organization
+func GetSourceCustomRepositoryRoles() ([]*github.CustomRepoRoles, error) {
+	client := newSourceGHRestClient()
+	ctx := context.WithValue(context.Background(), github.SleepUntilPrimaryRateLimitResetWhenRateLimited, true)
+
+	roles, _, err := client.Organizations.ListCustomRepoRoles(ctx, viper.Get("SOURCE_ORGANIZATION").(string))
+	if err != nil {
+		return nil, err
+	}
+	return roles.CustomRepoRoles, nil
+}
+
+// GetTargetCustomRepositoryRoles returns the custom repository roles defined in the target organization
+func GetTargetCustomRepositoryRoles() ([]*github.CustomRepoRoles, error) {
+	client := newGHRestClient()
+	ctx := context.WithValue(context.Background(), github.SleepUntilPrimaryRateLimitResetWhenRateLimited, true)
+
+	roles, _, err := client.Organizations.ListCustomRepoRoles(ctx, viper.Get("TARGET_ORGANIZATION").(string))
+	if err != nil {
+		return nil, err
+	}
+	return roles.CustomRepoRoles, nil
+}
+
+// CreateCustomRepositoryRole defines a custom repository role in the target organization
+func CreateCustomRepositoryRole(role *github.CustomRepoRoles) error {
+	client := newGHRestClient()
+	waitForWrite()
+	ctx := context.WithValue(context.Background(), github.SleepUntilPrimaryRateLimitResetWhenRateLimited, true)
+
+	_, resp, err := client.Organizations.CreateCustomRepoRole(ctx, viper.Get("TARGET_ORGANIZATION").(string), &github.CreateOrUpdateCustomRoleOptions{
+		Name:        role.Name,
+		Description: role.Description,
+		BaseRole:    role.BaseRole,
+		Permissions: role.Permissions,
+	})
+	record(journal.Entry{Action: journal.CustomRoleCreated, Permission: role.GetName()}, resp, err)
+	return err
+}
+
+// GetTargetExternalGroups returns the external IdP groups of an Enterprise Managed Users target organization
+func GetTargetExternalGroups() ([]map[string]string, error) {
+	client := newGHRestClient()
+	ctx := context.WithValue(context.Background(), github.SleepUntilPrimaryRateLimitResetWhenRateLimited, true)
+
+	groups := make([]map[string]string, 0)
+	opts := &github.ListExternalGroupsOptions{ListOptions: github.ListOptions{PerPage: 100}}
+	for {
+		page, resp, err := client.Teams.ListExternalGroups(ctx, viper.Get("TARGET_ORGANIZATION").(string), opts)
+		if err != nil {
+			return nil, err
+		}
+		for _, group := range page.Groups {
+			groups = append(groups, map[string]string{
+				"Id":   strconv.FormatInt(group.GetGroupID(), 10),
+				"Name": group.GetGroupName(),
+			})
+		}
+		if resp.NextPage == 0 {
+			break
+		}
+		opts.Page = resp.NextPage
+	}
+
+	return groups, nil
+}
+
+// GetTargetTeamExternalGroups returns the external groups connected to a target team
+func GetTargetTeamExternalGroups(slug string) ([]map[string]string, error) {
+	client := newGHRestClient()
+	ctx := context.WithValue(context.Background(), github.SleepUntilPrimaryRateLimitResetWhenRateLimited, true)
+
+	list, _, err := client.Teams.ListExternalGroupsForTeamBySlug(ctx, viper.Get("TARGET_ORGANIZATION").(string), slug)
+	if err != nil {
+		return nil, err
+	}
+
+	groups := make([]map[string]string, 0, len(list.Groups))
+	for _, group := range list.Groups {
+		groups = append(groups, map[string]string{
+			"Id":   strconv.FormatInt(group.GetGroupID(), 10),
+			"Name": group.GetGroupName(),
+		})
+	}
+	return groups, nil
+}
+
+// LinkTeamExternalGroup connects a target team to an external group, after which
+// GitHub manages the team's members from the group
+func LinkTeamExternalGroup(slug string, groupId int64) error {
+	client := newGHRestClient()
+	waitForWrite()
+	ctx := context.WithValue(context.Background(), github.SleepUntilPrimaryRateLimitResetWhenRateLimited, true)
+
+	_, resp, err := client.Teams.UpdateConnectedExternalGroup(ctx, viper.Get("TARGET_ORGANIZATION").(string), slug, &github.ExternalGroup{GroupID: &groupId})
+	record(journal.Entry{Action: journal.ExternalGroupLinked, Team: slug, Group: strconv.FormatInt(groupId, 10)}, resp, err)
+	return err
+}
+
+// UnlinkTeamExternalGroup removes the connection between a target team and its external group
+func UnlinkTeamExternalGroup(slug string) error {
+	client := newGHRestClient()
+	waitForWrite()
+	ctx := context.WithValue(context.Background(), github.SleepUntilPrimaryRateLimitResetWhenRateLimited, true)
+
+	resp, err := client.Teams.RemoveConnectedExternalGroup(ctx, viper.Get("TARGET_ORGANIZATION").(string), slug)
+	record(journal.Entry{Action: journal.ExternalGroupUnlinked, Team: slug}, resp, err)
+	return err
+}
+
+// GetSourceTeamIdPGroups returns the team synchronization IdP groups connected to a source team
+func GetSourceTeamIdPGroups(slug string) ([]map[string]string, error) {
+	return listTeamIdPGroups(newSourceGHRestClient(), viper.GetString("SOURCE_ORGANIZATION"), slug)
+}
+
+// GetTargetTeamIdPGroups returns the team synchronization IdP groups connected to a target team
+func GetTargetTeamIdPGroups(slug string) ([]map[string]string, error) {
+	return listTeamIdPGroups(newGHRestClient(), viper.GetString("TARGET_ORGANIZATION"), slug)
+}
+
+func listTeamIdPGroups(client *github.Client, organization string, slug string) ([]map[string]string, error) {
+	ctx := context.WithValue(context.Background(), github.SleepUntilPrimaryRateLimitResetWhenRateLimited, true)
+
+	list, _, err := client.Teams.ListIDPGroupsForTeamBySlug(ctx, organization, slug)
+	if err != nil {
+		return nil, err
+	}
+	return idpGroups(list.Groups), nil
+}
+
+// GetTargetIdPGroups returns the team synchronization IdP groups available in the target organization
+func GetTargetIdPGroups() ([]map[string]string, error) {
+	client := newGHRestClient()
+	ctx := context.WithValue(context.Background(), github.SleepUntilPrimaryRateLimitResetWhenRateLimited, true)
+
+	groups := make([]map[string]string, 0)
+	opts := &github.ListCursorOptions{PerPage: 100}
+	for {
+		list, resp, err := client.Teams.ListIDPGroupsInOrganization(ctx, viper.Get("TARGET_ORGANIZATION").(string), opts)
+		if err != nil {
+			return nil, err
+		}
+		groups = append(groups, idpGroups(list.Groups)...)
+
+		// The page of the next result is a token rather than a number
+		switch {
+		case resp.NextPageToken != "":
+			opts.Page = resp.NextPageToken
+		case resp.NextPage != 0:
+			opts.Page = strconv.Itoa(resp.NextPage)
+		default:
+			return groups, nil
+		}
+	}
+}
+
+func idpGroups(list []*github.IDPGroup) []map[string]string {
+	groups := make([]map[string]string, 0, len(list))
+	for _, group := range list {
+		groups = append(groups, map[string]string{
+			"Id":          group.GetGroupID(),
+			"Name":        group.GetGroupName(),
+			"Description": group.GetGroupDescription(),
+		})
+	}
+	return groups
+}
+
+// SetTeamIdPGroups replaces the team synchronization IdP groups connected to a target team
+func SetTeamIdPGroups(slug string, groups []map[string]string) error {
+	client := newGHRestClient()
+	waitForWrite()
+	ctx := context.WithValue(context.Background(), github.SleepUntilPrimaryRateLimitResetWhenRateLimited, true)
+
+	list := github.IDPGroupList{Groups: make([]*github.IDPGroup, 0, len(groups))}
+	ids := make([]string, 0, len(groups))
+	for _, group := range groups {
+		list.Groups = append(list.Groups, &github.IDPGroup{
+			GroupID:          github.String(group["Id"]),
+			GroupName:        github.String(group["Name"]),
+			GroupDescription: github.String(group["Description"]),
+		})
+		ids = append(ids, group["Id"])
+	}
+
+	_, resp, err := client.Teams.CreateOrUpdateIDPGroupConnectionsBySlug(ctx, viper.Get("TARGET_ORGANIZATION").(string), slug, list)
+	record(journal.Entry{Action: journal.IdPGroupsConnected, Team: slug, Group: strings.Join(ids, ";")}, resp, err)
+	return err
+}
+
+// GetSourceMemberIdentities returns the login, verified domain emails and SAML NameID of
+// the members of the source organization
+func GetSourceMemberIdentities() ([]map[string]string, error) {
+	client := newGHGraphqlClient()
+	return getMemberIdentities(client, viper.GetString("SOURCE_ORGANIZATION"), "")
+}
+
+// GetTargetMemberIdentities returns the login, verified domain emails and SAML NameID of the
+// members of the target organization. SAML identities are read from the enterprise when it is
+// set, as organizations of an enterprise with enterprise SAML have no identity provider of their own.
+func GetTargetMemberIdentities(enterprise string) ([]map[string]string, error) {
+	return getMemberIdentities(newTargetGHGraphqlClient(), viper.GetString("TARGET_ORGANIZATION"), enterprise)
+}
+
+func getMemberIdentities(client *RateLimitAwareGraphQLClient, organization string, enterprise string) ([]map[string]string, error) {
+	identities := make(map[string]map[string]string)
+	order := make([]string, 0)
+	identity := func(login string) map[string]string {
+		key := strings.ToLower(login)
+		if _, exists := identities[key]; !exists {
+			identities[key] = map[string]string{"Login": login, "Emails": "", "NameId": ""}
+			order = append(order, key)
+		}
+		return identities[key]
+	}
+
+	var membersQuery struct {
+		Organization struct {
+			MembersWithRole struct {
+				PageInfo struct {
+					EndCursor   githubv4.String
+					HasNextPage bool
+				}
+				Nodes []struct {
+					Login                            string
+					OrganizationVerifiedDomainEmails []string `graphql:"organizationVerifiedDomainEmails(login: $login)"`
+				}
+			} `graphql:"membersWithRole(first: $first, after: $after)"`
+		} `graphql:"organization(login: $login)"`
+	}
+
+	variables := map[string]interface{}{
+		"login": githubv4.String(organization),
+		"first": githubv4.Int(100),
+		"after": (*githubv4.String)(nil),
+	}
+	for {
+		if err := client.Query(context.Background(), &membersQuery, variables); err != nil {
+			return nil, err
+		}
+		for _, member := range membersQuery.Organization.MembersWithRole.Nodes {
+			identity(member.Login)["Emails"] = strings.Join(member.OrganizationVerifiedDomainEmails, ";")
+		}
+		if !membersQuery.Organization.MembersWithRole.PageInfo.HasNextPage {
+			break
+		}
+		variables["after"] = githubv4.NewString(membersQuery.Organization.MembersWithRole.PageInfo.EndCursor)
+	}
+
+	nameIds, err := getSAMLNameIds(client, organization, enterprise)
+	if err != nil {
+		return nil, err
+	}
+	for login, nameId := range nameIds {
+		identity(login)["NameId"] = nameId
+	}
+
+	result := make([]map[string]string, 0, len(order))
+	for _, key := range order {
+		result = append(result, identities[key])
+	}
+	return result, nil
+}
+
+type externalIdentities struct {
+	PageInfo struct {
+		EndCursor   githubv4.String
+		HasNextPage bool
+	}
+	Nodes []struct {
+		SamlIdentity *struct {
+			NameId string
+		}
+		User *struct {
+			Login string
+		}
+	}
+}
+
+// getSAMLNameIds returns the SAML NameID of each user linked to the identity provider of
+// the organization, or of the enterprise when it is set, keyed by login
+func getSAMLNameIds(client *RateLimitAwareGraphQLClient, organization string, enterprise string) (map[string]string, error) {
+	var organizationQuery struct {
+		Organization struct {
+			SamlIdentityProvider *struct {
+				ExternalIdentities externalIdentities `graphql:"externalIdentities(first: $first, after: $after)"`
+			}
+		} `graphql:"organization(login: $login)"`
+	}
+	var enterpriseQuery struct {
+		Enterprise struct {
+			OwnerInfo *struct {
+				SamlIdentityProvider *struct {
+					ExternalIdentities externalIdentities `graphql:"externalIdentities(first: $first, after: $after)"`
+				}
+			}
+		} `graphql:"enterprise(slug: $slug)"`
+	}
+
+	variables := map[string]interface{}{
+		"first": githubv4.Int(100),
+		"after": (*githubv4.String)(nil),
+	}
+	var query interface{} = &organizationQuery
+	if enterprise != "" {
+		variables["slug"] = githubv4.String(enterprise)
+		query = &enterpriseQuery
+	} else {
+		variables["login"] = githubv4.String(organization)
+	}
+
+	nameIds := make(map[string]string)
+	for {
+		if err := client.Query(context.Background(), query, variables); err != nil {
+			return nil, err
+		}
+
+		var page *externalIdentities
+		if enterprise != "" && enterpriseQuery.Enterprise.OwnerInfo != nil && enterpriseQuery.Enterprise.OwnerInfo.SamlIdentityProvider != nil {
+			page = &enterpriseQuery.Enterprise.OwnerInfo.SamlIdentityProvider.ExternalIdentities
+		} else if enterprise == "" && organizationQuery.Organization.SamlIdentityProvider != nil {
+			page = &organizationQuery.Organization.SamlIdentityProvider.ExternalIdentities
+		}
+		// No identity provider is configured
+		if page == nil {
+			return nameIds, nil
+		}
+
+		for _, node := range page.Nodes {
+			if node.User != nil && node.SamlIdentity != nil && node.SamlIdentity.NameId != "" {
+				nameIds[node.User.Login] = node.SamlIdentity.NameId
+			}
+		}
+		if !page.PageInfo.HasNextPage {
+			return nameIds, nil
+		}
+		variables["after"] = githubv4.NewString(page.PageInfo.EndCursor)
+	}
+}
@@ -0,0 +1,76 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/spf13/viper"
+)
+
+func TestGetSourceOrganizationTeamsReadsReviewExcludedMembers(t *testing.T) {
+	resetTokenSources(t)
+
+	excludedQueries := make([]string, 0)
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var request struct {
+			Query     string
+			Variables map[string]interface{}
+		}
+		if r.URL.Path != "/api/graphql" || json.NewDecoder(r.Body).Decode(&request) != nil {
+			http.NotFound(w, r)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		switch {
+		case strings.Contains(request.Query, "rateLimit"):
+			w.Write([]byte(`{"data": {"rateLimit": {"remaining": 5000, "resetAt": "2030-01-01T00:00:00Z"}}}`))
+			return
+		case strings.Contains(request.Query, "reviewRequestDelegationExcludedMembers"):
+			excludedQueries = append(excludedQueries, request.Variables["slug"].(string))
+			w.Write([]byte(`{"data": {"organization": {"team": {"reviewRequestDelegationExcludedMembers": {
+				"pageInfo": {"endCursor": "", "hasNextPage": false},
+				"nodes": [{"login": "mona"}, {"login": "hubot"}]}}}}}`))
+			return
+		}
+		w.Write([]byte(`{"data": {"organization": {"teams": {
+			"pageInfo": {"endCursor": "", "hasNextPage": false},
+			"edges": [
+				{"node": {"id": "T_1", "name": "Web", "slug": "web", "privacy": "VISIBLE", "parentTeam": null,
+					"notificationSetting": "NOTIFICATIONS_ENABLED", "reviewRequestDelegationEnabled": true,
+					"reviewRequestDelegationAlgorithm": "ROUND_ROBIN", "reviewRequestDelegationMemberCount": 2,
+					"reviewRequestDelegationNotifyTeam": false}},
+				{"node": {"id": "T_2", "name": "Ops", "slug": "ops", "privacy": "VISIBLE", "parentTeam": null,
+					"notificationSetting": "NOTIFICATIONS_ENABLED", "reviewRequestDelegationEnabled": false,
+					"reviewRequestDelegationAlgorithm": "", "reviewRequestDelegationMemberCount": 0,
+					"reviewRequestDelegationNotifyTeam": false}}
+			]}}}}`))
+	}))
+	defer server.Close()
+
+	transport := http.DefaultTransport
+	http.DefaultTransport = server.Client().Transport
+	t.Cleanup(func() {
+		http.DefaultTransport = transport
+		viper.Reset()
+	})
+	viper.Set("SOURCE_ORGANIZATION", "acme")
+	viper.Set("SOURCE_TOKEN", "token")
+	viper.Set("SOURCE_HOSTNAME", server.URL)
+
+	teams := GetSourceOrganizationTeams()
+	if len(teams) != 2 {
+		t.Fatalf("GetSourceOrganizationTeams() returned %d teams, want 2", len(teams))
+	}
+	if got := teams[0]["ReviewRequestDelegationExcludedMembers"]; got != "mona;hubot" {
+		t.Errorf("excluded members of web = %q, want %q", got, "mona;hubot")
+	}
+	if got, exists := teams[1]["ReviewRequestDelegationExcludedMembers"]; exists {
+		t.Errorf("excluded members of ops = %q, want none for a team without code review assignment", got)
+	}
+	if len(excludedQueries) != 1 || excludedQueries[0] != "web" {
+		t.Errorf("excluded members read for %v, want only web", excludedQueries)
+	}
+}
@@ -0,0 +1,160 @@
+package api
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/google/go-github/v62/github"
+	"github.com/jferrl/go-githubauth"
+	"github.com/spf13/viper"
+	"golang.org/x/oauth2"
+)
+
+// credentials are the personal access token or GitHub App installation used for one side of
+// the migration. The App is used when its ID and private key are set, and its installation is
+// looked up by organization when the installation ID is not set.
+type credentials struct {
+	side           string
+	token          string
+	appId          string
+	privateKey     string
+	installationId int64
+	organization   string
+	baseURL        string
+}
+
+var (
+	tokenSourcesMu sync.Mutex
+	tokenSources   = make(map[string]oauth2.TokenSource)
+)
+
+// sourceTokenSource returns the token source of the source organization, set by SOURCE_TOKEN or
+// SOURCE_APP_ID, SOURCE_PRIVATE_KEY and SOURCE_INSTALLATION_ID
+func sourceTokenSource() oauth2.TokenSource {
+	return cachedTokenSource(credentials{
+		side:           "source",
+		token:          viper.GetString("SOURCE_TOKEN"),
+		appId:          viper.GetString("SOURCE_APP_ID"),
+		privateKey:     viper.GetString("SOURCE_PRIVATE_KEY"),
+		installationId: viper.GetInt64("SOURCE_INSTALLATION_ID"),
+		organization:   viper.GetString("SOURCE_ORGANIZATION"),
+		baseURL:        restBaseURL(viper.GetString("SOURCE_HOSTNAME")),
+	})
+}
+
+// targetTokenSource returns the token source of the target organization, set by TARGET_TOKEN or
+// TARGET_APP_ID, TARGET_PRIVATE_KEY and TARGET_INSTALLATION_ID
+func targetTokenSource() oauth2.TokenSource {
+	return cachedTokenSource(credentials{
+		side:           "target",
+		token:          viper.GetString("TARGET_TOKEN"),
+		appId:          viper.GetString("TARGET_APP_ID"),
+		privateKey:     viper.GetString("TARGET_PRIVATE_KEY"),
+		installationId: viper.GetInt64("TARGET_INSTALLATION_ID"),
+		organization:   viper.GetString("TARGET_ORGANIZATION"),
+		baseURL:        restBaseURL(viper.GetString("TARGET_HOSTNAME")),
+	})
+}
+
+// cachedTokenSource creates the token source of a side once, so that every client of that side
+// shares one installation token, which is refreshed shortly before it expires during long runs.
+// An App is installed on each organization separately, so an installation that is looked up is
+// cached per organization, as sync byRepos may read repositories of several.
+func cachedTokenSource(c credentials) oauth2.TokenSource {
+	tokenSourcesMu.Lock()
+	defer tokenSourcesMu.Unlock()
+
+	key := c.side
+	if c.appId != "" && c.installationId == 0 {
+		key += "/" + strings.ToLower(c.organization)
+	}
+	if src, exists := tokenSources[key]; exists {
+		return src
+	}
+	src, err := c.tokenSource()
+	if err != nil {
+		log.Fatalf("Unable to authenticate to the %s - %v", c.side, err)
+	}
+	src = oauth2.ReuseTokenSource(nil, src)
+	tokenSources[key] = src
+	return src
+}
+
+func (c credentials) tokenSource() (oauth2.TokenSource, error) {
+	// check that the token or GitHub App values are set
+	if c.token == "" && (c.appId == "" || c.privateKey == "") {
+		return nil, fmt.Errorf("please provide a %s token or a %s GitHub App ID and private key", c.side, c.side)
+	}
+	if c.appId == "" || c.privateKey == "" {
+		// Personal access token authentication
+		return oauth2.StaticTokenSource(&oauth2.Token{AccessToken: c.token}), nil
+	}
+
+	// GitHub App authentication
+	appIdInt, err := strconv.ParseInt(c.appId, 10, 64)
+	if err != nil {
+		return nil, fmt.Errorf("error converting app ID to int64: %w", err)
+	}
+	privateKey, err := readPrivateKey(c.privateKey)
+	if err != nil {
+		return nil, err
+	}
+	appToken, err := githubauth.NewApplicationTokenSource(appIdInt, privateKey)
+	if err != nil {
+		return nil, fmt.Errorf("error creating app token: %w", err)
+	}
+
+	installationId := c.installationId
+	if installationId == 0 {
+		installationId, err = findInstallation(appToken, c.organization, c.baseURL)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	opts := []githubauth.InstallationTokenSourceOpt{}
+	if c.baseURL != "" {
+		opts = append(opts, githubauth.WithEnterpriseURLs(c.baseURL, c.baseURL))
+	}
+	return githubauth.NewInstallationTokenSource(installationId, appToken, opts...), nil
+}
+
+// readPrivateKey returns a PEM private key, which may be given as the path of the PEM file
+func readPrivateKey(value string) ([]byte, error) {
+	if strings.Contains(value, "-----BEGIN") {
+		return []byte(value), nil
+	}
+	key, err := os.ReadFile(value)
+	if err != nil {
+		return nil, fmt.Errorf("the private key is neither a PEM key nor a readable file: %w", err)
+	}
+	return key, nil
+}
+
+// findInstallation looks up the installation of the GitHub App on an organization
+func findInstallation(appToken oauth2.TokenSource, organization string, baseURL string) (int64, error) {
+	if organization == "" {
+		return 0, fmt.Errorf("an installation ID or an organization is needed to find the GitHub App installation")
+	}
+
+	client := github.NewClient(&http.Client{Transport: &oauth2.Transport{Source: appToken}})
+	if baseURL != "" {
+		enterpriseClient, err := client.WithEnterpriseURLs(baseURL, baseURL)
+		if err != nil {
+			return 0, err
+		}
+		client = enterpriseClient
+	}
+
+	installation, _, err := client.Apps.FindOrganizationInstallation(context.Background(), organization)
+	if err != nil {
+		return 0, fmt.Errorf("unable to find the GitHub App installation on %s: %w", organization, err)
+	}
+	return installation.GetID(), nil
+}
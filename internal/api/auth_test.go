@@ -0,0 +1,111 @@
+package api
+
+import (
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/pem"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"golang.org/x/oauth2"
+)
+
+func resetTokenSources(t *testing.T) {
+	t.Helper()
+	reset := func() {
+		tokenSourcesMu.Lock()
+		tokenSources = make(map[string]oauth2.TokenSource)
+		tokenSourcesMu.Unlock()
+	}
+	reset()
+	t.Cleanup(reset)
+}
+
+func privateKeyPEM(t *testing.T) string {
+	t.Helper()
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(pem.EncodeToMemory(&pem.Block{Type: "RSA PRIVATE KEY", Bytes: x509.MarshalPKCS1PrivateKey(key)}))
+}
+
+func TestTokenSourcePersonalAccessToken(t *testing.T) {
+	src, err := credentials{side: "source", token: "ghp_source"}.tokenSource()
+	if err != nil {
+		t.Fatalf("tokenSource() error = %v", err)
+	}
+	token, err := src.Token()
+	if err != nil || token.AccessToken != "ghp_source" {
+		t.Errorf("Token() = %v, %v, want ghp_source", token, err)
+	}
+}
+
+func TestTokenSourceMissingCredentials(t *testing.T) {
+	tests := map[string]credentials{
+		"nothing":         {side: "source"},
+		"app without key": {side: "source", appId: "1"},
+	}
+	for name, c := range tests {
+		t.Run(name, func(t *testing.T) {
+			if _, err := c.tokenSource(); err == nil || !strings.Contains(err.Error(), "source token") {
+				t.Errorf("tokenSource() error = %v, want a missing source token error", err)
+			}
+		})
+	}
+}
+
+func TestReadPrivateKey(t *testing.T) {
+	key := privateKeyPEM(t)
+	filename := filepath.Join(t.TempDir(), "app.pem")
+	if err := os.WriteFile(filename, []byte(key), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, value := range []string{key, filename} {
+		got, err := readPrivateKey(value)
+		if err != nil || string(got) != key {
+			t.Errorf("readPrivateKey(%.20q) = %.20q, %v, want the PEM key", value, got, err)
+		}
+	}
+	if _, err := readPrivateKey(filepath.Join(t.TempDir(), "missing.pem")); err == nil {
+		t.Error("readPrivateKey() expected an error for a missing file")
+	}
+}
+
+func TestTokenSourceGitHubAppInstallation(t *testing.T) {
+	resetTokenSources(t)
+
+	issued := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		switch {
+		case r.Method == http.MethodGet && r.URL.Path == "/api/v3/orgs/acme/installation":
+			w.Write([]byte(`{"id": 42}`))
+		case r.Method == http.MethodPost && r.URL.Path == "/api/v3/app/installations/42/access_tokens":
+			issued++
+			expiresAt := time.Now().Add(time.Hour).UTC().Format(time.RFC3339)
+			w.Write([]byte(`{"token": "ghs_installation", "expires_at": "` + expiresAt + `"}`))
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer server.Close()
+
+	c := credentials{side: "source", appId: "1", privateKey: privateKeyPEM(t), organization: "acme", baseURL: server.URL + "/api/v3/"}
+	for i := 0; i < 2; i++ {
+		token, err := cachedTokenSource(c).Token()
+		if err != nil || token.AccessToken != "ghs_installation" {
+			t.Fatalf("Token() = %v, %v, want ghs_installation", token, err)
+		}
+	}
+	if issued != 1 {
+		t.Errorf("issued %d installation tokens, want 1 reused until it expires", issued)
+	}
+}
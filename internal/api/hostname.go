@@ -0,0 +1,57 @@
+package api
+
+import (
+	"net/url"
+	"strings"
+)
+
+// host returns the host of a hostname given with or without a scheme, empty for GitHub.com
+func host(hostname string) string {
+	hostname = strings.TrimSpace(hostname)
+	if hostname == "" {
+		return ""
+	}
+	if !strings.Contains(hostname, "://") {
+		hostname = "https://" + hostname
+	}
+	u, err := url.Parse(hostname)
+	if err != nil || u.Host == "" {
+		return strings.Trim(hostname, "/")
+	}
+	h := strings.ToLower(u.Host)
+	if h == "github.com" || h == "api.github.com" {
+		return ""
+	}
+	return h
+}
+
+// isDataResidency reports whether a host is a GHE.com data residency tenant, such as acme.ghe.com
+func isDataResidency(h string) bool {
+	return strings.HasSuffix(h, ".ghe.com")
+}
+
+// restBaseURL returns the REST API URL of a hostname: https://api.<tenant>.ghe.com/ for GHE.com,
+// https://<hostname>/api/v3/ for GitHub Enterprise Server, and an empty URL for GitHub.com
+func restBaseURL(hostname string) string {
+	h := host(hostname)
+	switch {
+	case h == "":
+		return ""
+	case isDataResidency(h):
+		return "https://api." + strings.TrimPrefix(h, "api.") + "/"
+	}
+	return "https://" + h + "/api/v3/"
+}
+
+// graphqlURL returns the GraphQL API URL of a hostname: https://api.<tenant>.ghe.com/graphql for
+// GHE.com, https://<hostname>/api/graphql for GitHub Enterprise Server, and an empty URL for GitHub.com
+func graphqlURL(hostname string) string {
+	h := host(hostname)
+	switch {
+	case h == "":
+		return ""
+	case isDataResidency(h):
+		return "https://api." + strings.TrimPrefix(h, "api.") + "/graphql"
+	}
+	return "https://" + h + "/api/graphql"
+}
@@ -0,0 +1,43 @@
+package api
+
+import (
+	"net/http"
+	"testing"
+)
+
+func TestHostnameURLs(t *testing.T) {
+	tests := []struct {
+		hostname string
+		rest     string
+		graphql  string
+	}{
+		{"", "", ""},
+		{"github.com", "", ""},
+		{"https://github.com/", "", ""},
+		{"github.example.com", "https://github.example.com/api/v3/", "https://github.example.com/api/graphql"},
+		{"https://GitHub.example.com/", "https://github.example.com/api/v3/", "https://github.example.com/api/graphql"},
+		{"acme.ghe.com", "https://api.acme.ghe.com/", "https://api.acme.ghe.com/graphql"},
+		{"https://api.acme.ghe.com", "https://api.acme.ghe.com/", "https://api.acme.ghe.com/graphql"},
+	}
+	for _, tt := range tests {
+		if got := restBaseURL(tt.hostname); got != tt.rest {
+			t.Errorf("restBaseURL(%q) = %q, want %q", tt.hostname, got, tt.rest)
+		}
+		if got := graphqlURL(tt.hostname); got != tt.graphql {
+			t.Errorf("graphqlURL(%q) = %q, want %q", tt.hostname, got, tt.graphql)
+		}
+	}
+}
+
+func TestNewRestClient(t *testing.T) {
+	tests := map[string]string{
+		"":                   "https://api.github.com/",
+		"github.example.com": "https://github.example.com/api/v3/",
+		"acme.ghe.com":       "https://api.acme.ghe.com/",
+	}
+	for hostname, want := range tests {
+		if got := newRestClient(http.DefaultClient, hostname).BaseURL.String(); got != want {
+			t.Errorf("newRestClient(%q) base URL = %s, want %s", hostname, got, want)
+		}
+	}
+}
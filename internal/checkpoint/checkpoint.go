@@ -0,0 +1,189 @@
+package checkpoint
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"sync"
+)
+
+// record is a single line of the append-only state file
+type record struct {
+	Type  string          `json:"type"`
+	Run   string          `json:"run,omitempty"`
+	Key   string          `json:"key,omitempty"`
+	Value json.RawMessage `json:"value,omitempty"`
+}
+
+var (
+	mu        sync.Mutex
+	file      *os.File
+	filename  string
+	completed = make(map[string]bool)
+	data      = make(map[string]json.RawMessage)
+)
+
+// Open starts recording progress to the state file. When resume is set the progress of a
+// previous run is loaded first, which must have been started with the same run description.
+func Open(name string, run string, resume bool) error {
+	mu.Lock()
+	defer mu.Unlock()
+
+	filename = name
+	completed = make(map[string]bool)
+	data = make(map[string]json.RawMessage)
+
+	if resume {
+		size, err := load(run)
+		if err != nil {
+			return err
+		}
+		// Drop a partially written last line so new records start on a line of their own
+		if err := os.Truncate(filename, size); err != nil {
+			return err
+		}
+		f, err := os.OpenFile(filename, os.O_APPEND|os.O_WRONLY, 0644)
+		if err != nil {
+			return err
+		}
+		file = f
+		return nil
+	}
+
+	f, err := os.Create(filename)
+	if err != nil {
+		return err
+	}
+	file = f
+	return write(record{Type: "run", Run: run})
+}
+
+// load reads the state file and returns the size of its complete lines
+func load(run string) (int64, error) {
+	f, err := os.Open(filename)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return 0, fmt.Errorf("no state file %s to resume from", filename)
+		}
+		return 0, err
+	}
+	defer f.Close()
+
+	reader := bufio.NewReader(f)
+	var size int64
+	line := 0
+	for {
+		text, err := reader.ReadString('\n')
+		// A partially written last line is expected if the previous run was killed
+		if err != nil {
+			break
+		}
+
+		line++
+		var r record
+		if jsonErr := json.Unmarshal([]byte(text), &r); jsonErr != nil {
+			return 0, fmt.Errorf("state file %s is corrupt at line %d: %w", filename, line, jsonErr)
+		}
+		size += int64(len(text))
+
+		switch r.Type {
+		case "run":
+			if r.Run != run {
+				return 0, fmt.Errorf("state file %s belongs to a different run (%s)", filename, r.Run)
+			}
+		case "data":
+			data[r.Key] = r.Value
+		case "done":
+			completed[r.Key] = true
+		}
+	}
+
+	if line == 0 {
+		return 0, fmt.Errorf("state file %s is empty", filename)
+	}
+	return size, nil
+}
+
+func write(r record) error {
+	if file == nil {
+		return nil
+	}
+
+	line, err := json.Marshal(r)
+	if err != nil {
+		return err
+	}
+	_, err = file.Write(append(line, '\n'))
+	return err
+}
+
+// Done reports whether the work identified by key was finished in this or a resumed run
+func Done(key string) bool {
+	mu.Lock()
+	defer mu.Unlock()
+
+	return completed[strings.ToLower(key)]
+}
+
+// MarkDone records that the work identified by key has finished
+func MarkDone(key string) error {
+	mu.Lock()
+	defer mu.Unlock()
+
+	key = strings.ToLower(key)
+	if completed[key] {
+		return nil
+	}
+	completed[key] = true
+	return write(record{Type: "done", Key: key})
+}
+
+// Save stores fetched source data so a resumed run does not need to fetch it again
+func Save(key string, v interface{}) error {
+	value, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+
+	data[key] = value
+	return write(record{Type: "data", Key: key, Value: value})
+}
+
+// Load reads source data stored by Save, it returns false when nothing was stored for key
+func Load(key string, v interface{}) (bool, error) {
+	mu.Lock()
+	value, exists := data[key]
+	mu.Unlock()
+
+	if !exists {
+		return false, nil
+	}
+	return true, json.Unmarshal(value, v)
+}
+
+// Close stops recording progress. The state file is removed when the run finished,
+// as there is nothing left to resume.
+func Close(finished bool) error {
+	mu.Lock()
+	defer mu.Unlock()
+
+	if file == nil {
+		return nil
+	}
+	err := file.Close()
+	file = nil
+	if err != nil {
+		return err
+	}
+
+	if finished {
+		return os.Remove(filename)
+	}
+	return nil
+}
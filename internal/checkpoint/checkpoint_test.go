@@ -0,0 +1,70 @@
+package checkpoint
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestResume(t *testing.T) {
+	filename := filepath.Join(t.TempDir(), "state.jsonl")
+
+	if err := Open(filename, "sync a to b", false); err != nil {
+		t.Fatalf("Open() error = %v", err)
+	}
+	if err := Save("teams", []string{"team-a", "team-b"}); err != nil {
+		t.Fatalf("Save() error = %v", err)
+	}
+	if err := MarkDone("team:Team-A"); err != nil {
+		t.Fatalf("MarkDone() error = %v", err)
+	}
+	if err := Close(false); err != nil {
+		t.Fatalf("Close() error = %v", err)
+	}
+
+	// Simulate a run that was killed while writing a record
+	f, err := os.OpenFile(filename, os.O_APPEND|os.O_WRONLY, 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+	f.WriteString(`{"type":"done","key":"team:te`)
+	f.Close()
+
+	if err := Open(filename, "sync a to c", true); err == nil {
+		t.Error("Open() expected an error when resuming a different run")
+	}
+
+	if err := Open(filename, "sync a to b", true); err != nil {
+		t.Fatalf("Open() error = %v", err)
+	}
+	var teams []string
+	if loaded, err := Load("teams", &teams); err != nil || !loaded || len(teams) != 2 {
+		t.Errorf("Load() = %v, %v, %v, expected the saved teams", teams, loaded, err)
+	}
+	if !Done("team:team-a") {
+		t.Error("Done() = false, expected team-a to be done")
+	}
+	if Done("team:team-b") {
+		t.Error("Done() = true, expected team-b not to be done")
+	}
+	if err := MarkDone("team:team-b"); err != nil {
+		t.Fatalf("MarkDone() error = %v", err)
+	}
+	if err := Close(false); err != nil {
+		t.Fatalf("Close() error = %v", err)
+	}
+
+	// The partial record must not corrupt the records written after resuming
+	if err := Open(filename, "sync a to b", true); err != nil {
+		t.Fatalf("Open() error = %v", err)
+	}
+	if !Done("team:team-b") {
+		t.Error("Done() = false, expected team-b to be done")
+	}
+	if err := Close(true); err != nil {
+		t.Fatalf("Close() error = %v", err)
+	}
+	if _, err := os.Stat(filename); !os.IsNotExist(err) {
+		t.Error("Close() expected the state file to be removed")
+	}
+}
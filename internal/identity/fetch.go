@@ -0,0 +1,22 @@
+package identity
+
+import "github.com/mona-actions/gh-migrate-teams/internal/api"
+
+// GetSourceIdentities returns the identities of the members of the source organization
+func GetSourceIdentities() ([]Identity, error) {
+	data, err := api.GetSourceMemberIdentities()
+	if err != nil {
+		return nil, err
+	}
+	return FromData(data), nil
+}
+
+// GetTargetIdentities returns the identities of the members of the target organization, with
+// SAML identities read from the enterprise when it is set
+func GetTargetIdentities(enterprise string) ([]Identity, error) {
+	data, err := api.GetTargetMemberIdentities(enterprise)
+	if err != nil {
+		return nil, err
+	}
+	return FromData(data), nil
+}
@@ -0,0 +1,153 @@
+package identity
+
+import (
+	"encoding/csv"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+)
+
+const (
+	MatchEmail = "email"
+	MatchSAML  = "saml"
+
+	Resolved  = "resolved"
+	Ambiguous = "ambiguous"
+	Missing   = "missing"
+)
+
+// Identity is what identifies a user beyond their login: the emails of the organization's
+// verified domains and the NameID of their SAML identity
+type Identity struct {
+	Login  string
+	Emails []string
+	NameId string
+}
+
+// Resolution is the target user found for a source user. Only resolved users have a
+// target login, ambiguous users list the target users they matched instead.
+type Resolution struct {
+	Source     string
+	Target     string
+	Status     string
+	MatchedBy  string
+	Candidates []string
+}
+
+// ParseMethods reads a comma separated list of match methods, in the order they are tried
+func ParseMethods(value string) ([]string, error) {
+	methods := make([]string, 0)
+	for _, method := range strings.Split(value, ",") {
+		method = strings.ToLower(strings.TrimSpace(method))
+		switch method {
+		case "":
+			continue
+		case MatchEmail, MatchSAML:
+			methods = append(methods, method)
+		default:
+			return nil, fmt.Errorf("unknown identity match %q, expected %s or %s", method, MatchEmail, MatchSAML)
+		}
+	}
+	return methods, nil
+}
+
+// FromData converts the identities returned by the API, which separate emails with semicolons
+func FromData(data []map[string]string) []Identity {
+	identities := make([]Identity, 0, len(data))
+	for _, d := range data {
+		identity := Identity{Login: d["Login"], NameId: d["NameId"]}
+		for _, email := range strings.Split(d["Emails"], ";") {
+			if email = strings.TrimSpace(email); email != "" {
+				identity.Emails = append(identity.Emails, email)
+			}
+		}
+		identities = append(identities, identity)
+	}
+	return identities
+}
+
+// Resolve finds the target user of each source user by trying the methods in order. A method
+// that matches more than one target user makes the user ambiguous rather than guessing, and a
+// user no method matches is missing.
+func Resolve(sources []Identity, targets []Identity, methods []string) []Resolution {
+	byEmail := make(map[string][]string)
+	byNameId := make(map[string][]string)
+	for _, target := range targets {
+		for _, email := range target.Emails {
+			key := strings.ToLower(email)
+			byEmail[key] = appendUnique(byEmail[key], target.Login)
+		}
+		if target.NameId != "" {
+			key := strings.ToLower(target.NameId)
+			byNameId[key] = appendUnique(byNameId[key], target.Login)
+		}
+	}
+
+	resolutions := make([]Resolution, 0, len(sources))
+	for _, source := range sources {
+		resolution := Resolution{Source: source.Login, Status: Missing}
+		for _, method := range methods {
+			candidates := make([]string, 0)
+			switch method {
+			case MatchEmail:
+				for _, email := range source.Emails {
+					for _, login := range byEmail[strings.ToLower(email)] {
+						candidates = appendUnique(candidates, login)
+					}
+				}
+			case MatchSAML:
+				if source.NameId != "" {
+					candidates = byNameId[strings.ToLower(source.NameId)]
+				}
+			}
+
+			if len(candidates) == 1 {
+				resolution.Status = Resolved
+				resolution.Target = candidates[0]
+				resolution.MatchedBy = method
+				break
+			}
+			if len(candidates) > 1 {
+				resolution.Status = Ambiguous
+				resolution.MatchedBy = method
+				resolution.Candidates = append([]string(nil), candidates...)
+				sort.Strings(resolution.Candidates)
+				break
+			}
+		}
+		resolutions = append(resolutions, resolution)
+	}
+
+	return resolutions
+}
+
+func appendUnique(logins []string, login string) []string {
+	for _, l := range logins {
+		if strings.EqualFold(l, login) {
+			return logins
+		}
+	}
+	return append(logins, login)
+}
+
+// WriteReview writes the users that were not resolved to a CSV file for review, with their
+// status, the method that found several candidates and the candidates separated by semicolons
+func WriteReview(filename string, resolutions []Resolution) error {
+	file, err := os.Create(filename)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	writer := csv.NewWriter(file)
+	writer.Write([]string{"source_login", "status", "matched_by", "candidates"})
+	for _, r := range resolutions {
+		if r.Status == Resolved {
+			continue
+		}
+		writer.Write([]string{r.Source, r.Status, r.MatchedBy, strings.Join(r.Candidates, ";")})
+	}
+	writer.Flush()
+	return writer.Error()
+}
@@ -0,0 +1,87 @@
+package identity
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestParseMethods(t *testing.T) {
+	methods, err := ParseMethods(" SAML, email ,")
+	if err != nil {
+		t.Fatalf("ParseMethods() error = %v", err)
+	}
+	if want := []string{MatchSAML, MatchEmail}; !reflect.DeepEqual(methods, want) {
+		t.Errorf("ParseMethods() = %v, want %v", methods, want)
+	}
+
+	if _, err := ParseMethods("email,login"); err == nil {
+		t.Error("ParseMethods() expected an error for an unknown method")
+	}
+}
+
+func TestFromData(t *testing.T) {
+	identities := FromData([]map[string]string{{"Login": "alice", "Emails": "alice@acme.com; a@acme.com", "NameId": "alice@idp"}, {"Login": "bob"}})
+	want := []Identity{{Login: "alice", Emails: []string{"alice@acme.com", "a@acme.com"}, NameId: "alice@idp"}, {Login: "bob"}}
+	if !reflect.DeepEqual(identities, want) {
+		t.Errorf("FromData() = %+v, want %+v", identities, want)
+	}
+}
+
+func TestResolve(t *testing.T) {
+	sources := []Identity{
+		{Login: "alice", Emails: []string{"Alice@acme.com"}},
+		{Login: "bob", Emails: []string{"bob@acme.com"}, NameId: "bob@idp"},
+		{Login: "carol", Emails: []string{"team@acme.com"}, NameId: "carol@idp"},
+		{Login: "dave", NameId: "dave@idp"},
+		{Login: "erin"},
+	}
+	targets := []Identity{
+		{Login: "alice_acme", Emails: []string{"alice@acme.com"}},
+		{Login: "bob_acme", NameId: "BOB@idp"},
+		{Login: "shared-1", Emails: []string{"team@acme.com"}},
+		{Login: "shared-2", Emails: []string{"team@acme.com"}},
+		{Login: "carol_acme", NameId: "carol@idp"},
+		{Login: "dave-1", NameId: "dave@idp"},
+		{Login: "dave-2", NameId: "dave@idp"},
+	}
+
+	got := Resolve(sources, targets, []string{MatchEmail, MatchSAML})
+	want := []Resolution{
+		{Source: "alice", Target: "alice_acme", Status: Resolved, MatchedBy: MatchEmail},
+		{Source: "bob", Target: "bob_acme", Status: Resolved, MatchedBy: MatchSAML},
+		// An ambiguous match is not resolved by a later method
+		{Source: "carol", Status: Ambiguous, MatchedBy: MatchEmail, Candidates: []string{"shared-1", "shared-2"}},
+		{Source: "dave", Status: Ambiguous, MatchedBy: MatchSAML, Candidates: []string{"dave-1", "dave-2"}},
+		{Source: "erin", Status: Missing},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Resolve() =\n%+v\nwant\n%+v", got, want)
+	}
+
+	if got := Resolve(sources[:1], targets, []string{MatchSAML}); got[0].Status != Missing {
+		t.Errorf("Resolve() with saml only = %+v, want alice to be missing", got[0])
+	}
+}
+
+func TestWriteReview(t *testing.T) {
+	filename := filepath.Join(t.TempDir(), "review.csv")
+	resolutions := []Resolution{
+		{Source: "alice", Target: "alice_acme", Status: Resolved, MatchedBy: MatchEmail},
+		{Source: "carol", Status: Ambiguous, MatchedBy: MatchEmail, Candidates: []string{"shared-1", "shared-2"}},
+		{Source: "erin", Status: Missing},
+	}
+	if err := WriteReview(filename, resolutions); err != nil {
+		t.Fatalf("WriteReview() error = %v", err)
+	}
+
+	content, err := os.ReadFile(filename)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := "source_login,status,matched_by,candidates\ncarol,ambiguous,email,shared-1;shared-2\nerin,missing,,\n"
+	if string(content) != want {
+		t.Errorf("WriteReview() wrote\n%s\nwant\n%s", content, want)
+	}
+}
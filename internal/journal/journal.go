@@ -0,0 +1,150 @@
+package journal
+
+import (
+	"bufio"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"os"
+	"sync"
+	"time"
+)
+
+const (
+	TeamCreated       = "team-created"
+	TeamUpdated       = "team-updated"
+	TeamDeleted       = "team-deleted"
+	MemberAdded       = "member-added"
+	MemberRoleChanged = "member-role-changed"
+	MemberRemoved     = "member-removed"
+	RepositoryGranted = "repository-granted"
+	RepositoryChanged = "repository-permission-changed"
+	RepositoryRevoked = "repository-revoked"
+
+	CollaboratorAdded   = "collaborator-added"
+	CollaboratorInvited = "collaborator-invited"
+	CollaboratorChanged = "collaborator-permission-changed"
+	CollaboratorRemoved = "collaborator-removed"
+
+	CustomRoleCreated = "custom-role-created"
+
+	ExternalGroupLinked   = "external-group-linked"
+	ExternalGroupUnlinked = "external-group-unlinked"
+	IdPGroupsConnected    = "idp-groups-connected"
+)
+
+// Entry is a single write made to the target organization. Writes that change an existing role
+// or permission keep the one they replaced in Previous, so that it can be restored.
+type Entry struct {
+	RunId        string    `json:"run_id"`
+	Timestamp    time.Time `json:"timestamp"`
+	Action       string    `json:"action"`
+	Organization string    `json:"organization"`
+	Team         string    `json:"team"`
+	Member       string    `json:"member,omitempty"`
+	Role         string    `json:"role,omitempty"`
+	Repository   string    `json:"repository,omitempty"`
+	Permission   string    `json:"permission,omitempty"`
+	Previous     string    `json:"previous,omitempty"`
+	Group        string    `json:"group,omitempty"`
+	Status       int       `json:"status"`
+	Error        string    `json:"error,omitempty"`
+}
+
+// Succeeded reports whether GitHub accepted the write
+func (e Entry) Succeeded() bool {
+	return e.Error == "" && e.Status >= 200 && e.Status < 300
+}
+
+var (
+	mu    sync.Mutex
+	file  *os.File
+	runId string
+)
+
+// NewRunId returns an identifier for a run that sorts by the time it started
+func NewRunId() string {
+	suffix := make([]byte, 3)
+	rand.Read(suffix)
+	return time.Now().UTC().Format("20060102T150405Z") + "-" + hex.EncodeToString(suffix)
+}
+
+// Open appends the writes of the run to the journal file until Close is called
+func Open(filename string, id string) error {
+	mu.Lock()
+	defer mu.Unlock()
+
+	f, err := os.OpenFile(filename, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+	if err != nil {
+		return err
+	}
+	file = f
+	runId = id
+	return nil
+}
+
+// Record appends an entry to the journal, it does nothing when no journal is open
+func Record(e Entry) error {
+	mu.Lock()
+	defer mu.Unlock()
+
+	if file == nil {
+		return nil
+	}
+
+	e.RunId = runId
+	e.Timestamp = time.Now().UTC()
+	line, err := json.Marshal(e)
+	if err != nil {
+		return err
+	}
+	_, err = file.Write(append(line, '\n'))
+	return err
+}
+
+func Close() error {
+	mu.Lock()
+	defer mu.Unlock()
+
+	if file == nil {
+		return nil
+	}
+	err := file.Close()
+	file = nil
+	return err
+}
+
+// Read returns the entries recorded for a run, in the order they were written
+func Read(filename string, id string) ([]Entry, error) {
+	f, err := os.Open(filename)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	entries := make([]Entry, 0)
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+	line := 0
+	for scanner.Scan() {
+		line++
+		if len(scanner.Bytes()) == 0 {
+			continue
+		}
+
+		var e Entry
+		if err := json.Unmarshal(scanner.Bytes(), &e); err != nil {
+			return nil, fmt.Errorf("journal %s is corrupt at line %d: %w", filename, line, err)
+		}
+		if e.RunId == id {
+			entries = append(entries, e)
+		}
+	}
+
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+
+	return entries, nil
+}
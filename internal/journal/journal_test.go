@@ -0,0 +1,49 @@
+package journal
+
+import (
+	"path/filepath"
+	"testing"
+)
+
+func TestRecordAndRead(t *testing.T) {
+	filename := filepath.Join(t.TempDir(), "journal.jsonl")
+
+	if err := Open(filename, "first"); err != nil {
+		t.Fatalf("Open() error = %v", err)
+	}
+	Record(Entry{Action: TeamCreated, Organization: "target", Team: "team1", Status: 201})
+	Record(Entry{Action: MemberAdded, Organization: "target", Team: "team1", Member: "user1", Status: 422, Error: "validation failed"})
+	Close()
+
+	// A second run appends to the same journal
+	if err := Open(filename, "second"); err != nil {
+		t.Fatalf("Open() error = %v", err)
+	}
+	Record(Entry{Action: RepositoryChanged, Organization: "target", Team: "team2", Repository: "repo1", Permission: "push", Previous: "pull", Status: 204})
+	Close()
+
+	// Nothing is recorded once the journal is closed
+	Record(Entry{Action: TeamDeleted, Team: "team3"})
+
+	entries, err := Read(filename, "first")
+	if err != nil {
+		t.Fatalf("Read() error = %v", err)
+	}
+	if len(entries) != 2 {
+		t.Fatalf("Read() returned %d entries, want 2", len(entries))
+	}
+	if entries[0].Action != TeamCreated || !entries[0].Succeeded() {
+		t.Errorf("entries[0] = %+v, want successful %s", entries[0], TeamCreated)
+	}
+	if entries[1].Member != "user1" || entries[1].Succeeded() {
+		t.Errorf("entries[1] = %+v, want failed add of user1", entries[1])
+	}
+
+	entries, err = Read(filename, "second")
+	if err != nil {
+		t.Fatalf("Read() error = %v", err)
+	}
+	if len(entries) != 1 || entries[0].Repository != "repo1" || entries[0].Previous != "pull" {
+		t.Errorf("Read(second) = %+v, want the change of repo1 from pull", entries)
+	}
+}
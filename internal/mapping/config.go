@@ -0,0 +1,51 @@
+package mapping
+
+import (
+	"sync"
+
+	"github.com/spf13/viper"
+)
+
+var (
+	loadedMu sync.RWMutex
+	loaded   = make(map[string]*Mapping)
+)
+
+// LoadConfigured reads the user, team and repository mappings set by MAPPING_FILE (in the
+// MAPPING_FORMAT format), TEAM_MAPPING_FILE and REPO_MAPPING_FILE, so that they are read once
+// per run rather than for every team or member. Mappings that are not set are empty.
+func LoadConfigured() error {
+	files := []struct {
+		kind     string
+		filename string
+		format   string
+	}{
+		{Users, viper.GetString("MAPPING_FILE"), viper.GetString("MAPPING_FORMAT")},
+		{Teams, viper.GetString("TEAM_MAPPING_FILE"), ""},
+		{Repositories, viper.GetString("REPO_MAPPING_FILE"), ""},
+	}
+
+	mappings := make(map[string]*Mapping)
+	for _, f := range files {
+		if f.filename == "" {
+			continue
+		}
+		m, err := Load(f.filename, f.kind, f.format)
+		if err != nil {
+			return err
+		}
+		mappings[f.kind] = m
+	}
+
+	loadedMu.Lock()
+	loaded = mappings
+	loadedMu.Unlock()
+	return nil
+}
+
+// Get returns the mapping of the given kind read by LoadConfigured, nil when it is not set
+func Get(kind string) *Mapping {
+	loadedMu.RLock()
+	defer loadedMu.RUnlock()
+	return loaded[kind]
+}
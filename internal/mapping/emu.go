@@ -0,0 +1,64 @@
+package mapping
+
+import (
+	"encoding/csv"
+	"os"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+var (
+	invalidLoginCharacters = regexp.MustCompile(`[^A-Za-z0-9-]+`)
+	repeatedDashes         = regexp.MustCompile(`-{2,}`)
+)
+
+// ShortcodeLogin derives the login an enterprise with managed users provisions for a user: the
+// normalized user name, an underscore and the enterprise shortcode. The user name is normalized as
+// GitHub does, dropping anything from an @, turning characters other than letters, digits and
+// dashes, underscores included, into dashes and removing repeated, leading and trailing dashes. A
+// login that already ends in the shortcode is kept, and one that ends in sourceShortcode, as managed
+// user logins of a source enterprise do, has it replaced.
+func ShortcodeLogin(login string, shortcode string, sourceShortcode string) string {
+	shortcode = strings.TrimPrefix(strings.TrimSpace(shortcode), "_")
+	sourceShortcode = strings.TrimPrefix(strings.TrimSpace(sourceShortcode), "_")
+	name := strings.TrimSpace(login)
+	if i := strings.Index(name, "@"); i >= 0 {
+		name = name[:i]
+	}
+	if strings.HasSuffix(strings.ToLower(name), "_"+strings.ToLower(shortcode)) {
+		return name
+	}
+	if sourceShortcode != "" && strings.HasSuffix(strings.ToLower(name), "_"+strings.ToLower(sourceShortcode)) {
+		name = name[:len(name)-len(sourceShortcode)-1]
+	}
+
+	name = invalidLoginCharacters.ReplaceAllString(name, "-")
+	name = repeatedDashes.ReplaceAllString(name, "-")
+	name = strings.Trim(name, "-")
+	return name + "_" + shortcode
+}
+
+// WriteUnresolved writes the source logins whose derived login was not found in the target
+// organization to a CSV file, with the login they were derived to, sorted by source login
+func WriteUnresolved(filename string, unresolved map[string]string) error {
+	file, err := os.Create(filename)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	sources := make([]string, 0, len(unresolved))
+	for source := range unresolved {
+		sources = append(sources, source)
+	}
+	sort.Strings(sources)
+
+	writer := csv.NewWriter(file)
+	writer.Write([]string{"source_login", "derived_login"})
+	for _, source := range sources {
+		writer.Write([]string{source, unresolved[source]})
+	}
+	writer.Flush()
+	return writer.Error()
+}
@@ -0,0 +1,51 @@
+package mapping
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestShortcodeLogin(t *testing.T) {
+	tests := map[string]string{
+		"octocat":              "octocat_acme",
+		"mona.lisa":            "mona-lisa_acme",
+		"mona..lisa-":          "mona-lisa_acme",
+		"mona.lisa@github.com": "mona-lisa_acme",
+		"octocat_acme":         "octocat_acme",
+		"Octocat_ACME":         "Octocat_ACME",
+		"octocat_old":          "octocat_acme",
+		"john_smith":           "john-smith_acme",
+		"john_smith_old":       "john-smith_acme",
+		"-first+last-":         "first-last_acme",
+	}
+
+	for login, want := range tests {
+		if got := ShortcodeLogin(login, "acme", "old"); got != want {
+			t.Errorf("ShortcodeLogin(%q) = %q, want %q", login, got, want)
+		}
+	}
+
+	if got := ShortcodeLogin("john_smith", "acme", ""); got != "john-smith_acme" {
+		t.Errorf("ShortcodeLogin() without a source shortcode = %q, want john-smith_acme", got)
+	}
+	if got := ShortcodeLogin("octocat", "_acme", ""); got != "octocat_acme" {
+		t.Errorf("ShortcodeLogin() with a leading underscore = %q, want octocat_acme", got)
+	}
+}
+
+func TestWriteUnresolved(t *testing.T) {
+	filename := filepath.Join(t.TempDir(), "unresolved.csv")
+	if err := WriteUnresolved(filename, map[string]string{"mona.lisa": "mona-lisa_acme", "hubot": "hubot_acme"}); err != nil {
+		t.Fatalf("WriteUnresolved() error = %v", err)
+	}
+
+	content, err := os.ReadFile(filename)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := "source_login,derived_login\nhubot,hubot_acme\nmona.lisa,mona-lisa_acme\n"
+	if string(content) != want {
+		t.Errorf("WriteUnresolved() wrote\n%s\nwant\n%s", content, want)
+	}
+}
@@ -0,0 +1,386 @@
+package mapping
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+const (
+	Users        = "users"
+	Teams        = "teams"
+	Repositories = "repositories"
+
+	FormatCSV  = "csv"
+	FormatGEI  = "gei"
+	FormatJSON = "json"
+	FormatYAML = "yaml"
+)
+
+// GEIColumns are the columns of the CSV written by gh gei generate-mannequin-csv and read
+// by gh gei reclaim-mannequin
+var GEIColumns = []string{"mannequin-user", "mannequin-id", "target-user"}
+
+// Mapping translates source names of one kind to target names. Names are matched without
+// regard to case, as GitHub does. A nil mapping maps nothing.
+type Mapping struct {
+	Kind     string
+	Filename string
+	targets  map[string]string
+	sources  map[string]string
+	at       map[string]string
+}
+
+// entry is a source and target read from a mapping file, with where it was read, such as line 3
+type entry struct {
+	source string
+	target string
+	at     string
+}
+
+// Target returns the target name of a source name and whether the source is mapped
+func (m *Mapping) Target(source string) (string, bool) {
+	if m == nil {
+		return "", false
+	}
+	target, exists := m.targets[strings.ToLower(source)]
+	return target, exists
+}
+
+// Lookup returns the target name of a source name, or the source name when it is not mapped
+func (m *Mapping) Lookup(source string) string {
+	if target, exists := m.Target(source); exists {
+		return target
+	}
+	return source
+}
+
+// Sources returns the mapped source names as they are written in the mapping file, sorted
+func (m *Mapping) Sources() []string {
+	if m == nil {
+		return nil
+	}
+	sources := make([]string, 0, len(m.sources))
+	for _, source := range m.sources {
+		sources = append(sources, source)
+	}
+	sort.Strings(sources)
+	return sources
+}
+
+// Len returns the number of mapped source names
+func (m *Mapping) Len() int {
+	if m == nil {
+		return 0
+	}
+	return len(m.targets)
+}
+
+// Load reads a mapping file of the given kind. The format is csv, gei, json or yaml. An empty
+// format is detected from the file extension, and for CSV files from the header.
+//
+// CSV files are read by header name: the source column is named source or starts with source,
+// the target column is named target or starts with target. The gei format is a mannequin reclaim
+// CSV, where rows without a target user are skipped. The name of the file does not select the
+// format. JSON and YAML files hold either an object of source to target names or a list of objects
+// with source and target fields.
+//
+// A source that is listed twice with different targets is an error, as is, for users, a target
+// that more than one source maps to.
+func Load(filename string, kind string, format string) (*Mapping, error) {
+	content, err := os.ReadFile(filename)
+	if err != nil {
+		return nil, err
+	}
+
+	format = strings.ToLower(strings.TrimSpace(format))
+	if format == "" {
+		format = detectFormat(filename)
+	}
+
+	var entries []entry
+	switch format {
+	case FormatCSV, FormatGEI:
+		entries, err = readCSV(content, format)
+	case FormatJSON:
+		entries, err = readJSON(content)
+	case FormatYAML, "yml":
+		entries, err = readYAML(content)
+	default:
+		return nil, fmt.Errorf("unknown mapping format %q, expected %s, %s, %s or %s", format, FormatCSV, FormatGEI, FormatJSON, FormatYAML)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("%s mapping file %s: %w", kind, filename, err)
+	}
+
+	m := &Mapping{
+		Kind:     kind,
+		Filename: filename,
+		targets:  make(map[string]string),
+		sources:  make(map[string]string),
+		at:       make(map[string]string),
+	}
+	if err := m.add(entries); err != nil {
+		return nil, fmt.Errorf("%s mapping file %s: %w", kind, filename, err)
+	}
+	return m, nil
+}
+
+// add adds entries to the mapping, reporting every conflicting entry. A source that is listed
+// again with the same target is not a conflict, mannequin CSVs list a login once per mannequin.
+func (m *Mapping) add(entries []entry) error {
+	byTarget := make(map[string]string)
+	problems := make([]string, 0)
+	for _, e := range entries {
+		key := strings.ToLower(e.source)
+		if target, exists := m.targets[key]; exists {
+			if !strings.EqualFold(target, e.target) {
+				problems = append(problems, fmt.Sprintf("%s: %s is mapped to %s and to %s on %s", e.at, e.source, e.target, target, m.at[key]))
+			}
+			continue
+		}
+
+		if m.Kind == Users {
+			if source, exists := byTarget[strings.ToLower(e.target)]; exists {
+				problems = append(problems, fmt.Sprintf("%s: %s and %s on %s are both mapped to %s", e.at, e.source, source, m.at[strings.ToLower(source)], e.target))
+				continue
+			}
+			byTarget[strings.ToLower(e.target)] = e.source
+		}
+
+		m.targets[key] = e.target
+		m.sources[key] = e.source
+		m.at[key] = e.at
+	}
+
+	if len(problems) > 0 {
+		return errors.New(strings.Join(problems, "; "))
+	}
+	return nil
+}
+
+// detectFormat returns the format of a mapping file from its extension, CSV by default
+func detectFormat(filename string) string {
+	switch strings.ToLower(filepath.Ext(filename)) {
+	case ".json":
+		return FormatJSON
+	case ".yaml", ".yml":
+		return FormatYAML
+	}
+	return FormatCSV
+}
+
+// readCSV reads a CSV mapping file by header name. The csv format falls back to gei when the
+// header has the mannequin columns.
+func readCSV(content []byte, format string) ([]entry, error) {
+	reader := csv.NewReader(bytes.NewReader(content))
+	reader.FieldsPerRecord = -1 // Allow variable number of fields per record
+	records := make([][]string, 0)
+	lines := make([]int, 0)
+	for {
+		record, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		line, _ := reader.FieldPos(0)
+		records = append(records, record)
+		lines = append(lines, line)
+	}
+	if len(records) == 0 {
+		return nil, nil
+	}
+
+	header := make(map[string]int)
+	for i, column := range records[0] {
+		header[strings.ToLower(strings.TrimSpace(column))] = i
+	}
+	if _, exists := header["mannequin-user"]; exists && format == FormatCSV {
+		format = FormatGEI
+	}
+
+	var source, target int
+	if format == FormatGEI {
+		var userExists, targetExists bool
+		source, userExists = header["mannequin-user"]
+		target, targetExists = header["target-user"]
+		if !userExists || !targetExists {
+			return nil, fmt.Errorf("not a mannequin CSV, expected the columns %s", strings.Join(GEIColumns, ","))
+		}
+	} else {
+		source, target = findColumn(records[0], "source"), findColumn(records[0], "target")
+		if source < 0 || target < 0 {
+			return nil, fmt.Errorf("line %d: expected a header with a source and a target column, got %q", lines[0], strings.Join(records[0], ","))
+		}
+	}
+
+	entries := make([]entry, 0, len(records)-1)
+	for i, record := range records[1:] { // Skip header
+		line := lines[i+1]
+		if strings.TrimSpace(strings.Join(record, "")) == "" {
+			continue
+		}
+		if len(record) <= source || strings.TrimSpace(record[source]) == "" {
+			return nil, fmt.Errorf("line %d: missing the source column %s", line, records[0][source])
+		}
+
+		value := ""
+		if len(record) > target {
+			value = strings.TrimSpace(record[target])
+		}
+		if value == "" {
+			// Mannequins that are not reclaimed leave the target user empty or out
+			if format == FormatGEI {
+				continue
+			}
+			return nil, fmt.Errorf("line %d: missing the target column %s", line, records[0][target])
+		}
+		entries = append(entries, entry{source: strings.TrimSpace(record[source]), target: value, at: fmt.Sprintf("line %d", line)})
+	}
+	return entries, nil
+}
+
+// findColumn returns the index of the column named prefix, or else of the first column whose
+// name starts with prefix, such as source_login. It returns -1 when there is no such column.
+func findColumn(header []string, prefix string) int {
+	found := -1
+	for i, column := range header {
+		column = strings.ToLower(strings.TrimSpace(column))
+		if column == prefix {
+			return i
+		}
+		if found < 0 && strings.HasPrefix(column, prefix) {
+			found = i
+		}
+	}
+	return found
+}
+
+// readJSON reads an object of source to target names, keeping duplicate keys so that they can be
+// reported, or a list of objects with source and target fields
+func readJSON(content []byte) ([]entry, error) {
+	decoder := json.NewDecoder(bytes.NewReader(content))
+	token, err := decoder.Token()
+	if err == io.EOF {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	switch token {
+	case json.Delim('{'):
+		entries := make([]entry, 0)
+		for decoder.More() {
+			key, err := decoder.Token()
+			if err != nil {
+				return nil, err
+			}
+			at := fmt.Sprintf("line %d", 1+bytes.Count(content[:decoder.InputOffset()], []byte("\n")))
+			var value string
+			if err := decoder.Decode(&value); err != nil {
+				return nil, fmt.Errorf("%s: the target of %v must be a string", at, key)
+			}
+			entries = append(entries, entry{source: key.(string), target: value, at: at})
+		}
+		return checkEntries(entries)
+	case json.Delim('['):
+		var list []map[string]interface{}
+		if err := json.Unmarshal(content, &list); err != nil {
+			return nil, fmt.Errorf("expected a list of objects with source and target fields: %w", err)
+		}
+		return listEntries(list)
+	}
+	return nil, errors.New("expected an object of source to target names or a list of objects with source and target fields")
+}
+
+// readYAML reads a mapping of source to target names, keeping duplicate keys so that they can be
+// reported, or a list of mappings with source and target fields
+func readYAML(content []byte) ([]entry, error) {
+	var document yaml.Node
+	if err := yaml.Unmarshal(content, &document); err != nil {
+		return nil, err
+	}
+	if len(document.Content) == 0 {
+		return nil, nil
+	}
+
+	root := document.Content[0]
+	switch root.Kind {
+	case yaml.MappingNode:
+		entries := make([]entry, 0, len(root.Content)/2)
+		for i := 0; i+1 < len(root.Content); i += 2 {
+			key, value := root.Content[i], root.Content[i+1]
+			if value.Kind != yaml.ScalarNode {
+				return nil, fmt.Errorf("line %d: the target of %s must be a string", value.Line, key.Value)
+			}
+			entries = append(entries, entry{source: key.Value, target: value.Value, at: fmt.Sprintf("line %d", key.Line)})
+		}
+		return checkEntries(entries)
+	case yaml.SequenceNode:
+		entries := make([]entry, 0, len(root.Content))
+		for _, item := range root.Content {
+			var fields map[string]interface{}
+			if err := item.Decode(&fields); err != nil {
+				return nil, fmt.Errorf("line %d: expected source and target fields: %w", item.Line, err)
+			}
+			e, err := fieldsEntry(fields, fmt.Sprintf("line %d", item.Line))
+			if err != nil {
+				return nil, err
+			}
+			entries = append(entries, e)
+		}
+		return entries, nil
+	}
+	return nil, errors.New("expected a mapping of source to target names or a list of source and target fields")
+}
+
+// listEntries converts a list of objects with source and target fields, numbered from 1
+func listEntries(list []map[string]interface{}) ([]entry, error) {
+	entries := make([]entry, 0, len(list))
+	for i, fields := range list {
+		e, err := fieldsEntry(fields, fmt.Sprintf("entry %d", i+1))
+		if err != nil {
+			return nil, err
+		}
+		entries = append(entries, e)
+	}
+	return entries, nil
+}
+
+// fieldsEntry converts an object with source and target fields
+func fieldsEntry(fields map[string]interface{}, at string) (entry, error) {
+	source, sourceOk := fields["source"].(string)
+	target, targetOk := fields["target"].(string)
+	if !sourceOk || !targetOk {
+		return entry{}, fmt.Errorf("%s: expected string source and target fields", at)
+	}
+	e := entry{source: strings.TrimSpace(source), target: strings.TrimSpace(target), at: at}
+	if e.source == "" || e.target == "" {
+		return entry{}, fmt.Errorf("%s: the source and target must not be empty", at)
+	}
+	return e, nil
+}
+
+// checkEntries trims the entries of a source to target object and rejects empty names
+func checkEntries(entries []entry) ([]entry, error) {
+	for i := range entries {
+		entries[i].source = strings.TrimSpace(entries[i].source)
+		entries[i].target = strings.TrimSpace(entries[i].target)
+		if entries[i].source == "" || entries[i].target == "" {
+			return nil, fmt.Errorf("%s: the source and target must not be empty", entries[i].at)
+		}
+	}
+	return entries, nil
+}
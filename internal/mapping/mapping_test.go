@@ -0,0 +1,149 @@
+package mapping
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/spf13/viper"
+)
+
+func writeFile(t *testing.T, name string, content string) string {
+	t.Helper()
+	filename := filepath.Join(t.TempDir(), name)
+	if err := os.WriteFile(filename, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+	return filename
+}
+
+func targets(m *Mapping) map[string]string {
+	got := make(map[string]string)
+	for _, source := range m.Sources() {
+		got[source], _ = m.Target(source)
+	}
+	return got
+}
+
+func TestLoad(t *testing.T) {
+	tests := []struct {
+		name    string
+		file    string
+		format  string
+		content string
+		want    map[string]string
+	}{
+		{"csv", "mapping.csv", FormatCSV, "source,target\nflastname,firstname.lastname\n\nhubot,hubot_acme\n", map[string]string{"flastname": "firstname.lastname", "hubot": "hubot_acme"}},
+		{"csv by header", "mapping.csv", "", "target_login,source_login\noctocat_acme,octocat\n", map[string]string{"octocat": "octocat_acme"}},
+		{"gei by header", "mapping.csv", FormatGEI, "target-user,mannequin-id,mannequin-user\nocto_acme,M_1,octocat\n,M_2,hubot\n", map[string]string{"octocat": "octo_acme"}},
+		{"gei detected", "mapping.csv", "", "mannequin-user,mannequin-id,target-user\noctocat,M_1,octo_acme\nhubot,M_2\noctocat,M_3,octo_acme\n", map[string]string{"octocat": "octo_acme"}},
+		{"json object", "mapping.json", "", `{"octocat": "octo_acme", "hubot": "hubot_acme"}`, map[string]string{"octocat": "octo_acme", "hubot": "hubot_acme"}},
+		{"json list", "mapping.txt", FormatJSON, `[{"source": "octocat", "target": "octo_acme"}]`, map[string]string{"octocat": "octo_acme"}},
+		{"yaml mapping", "mapping.yml", "", "octocat: octo_acme\nhubot: hubot_acme\n", map[string]string{"octocat": "octo_acme", "hubot": "hubot_acme"}},
+		{"yaml list", "mapping.yaml", "", "- source: octocat\n  target: octo_acme\n", map[string]string{"octocat": "octo_acme"}},
+		{"empty", "mapping.csv", "", "", map[string]string{}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m, err := Load(writeFile(t, tt.file, tt.content), Users, tt.format)
+			if err != nil {
+				t.Fatalf("Load() error = %v", err)
+			}
+			if got := targets(m); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Load() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestLoadErrors(t *testing.T) {
+	tests := map[string]struct {
+		file    string
+		kind    string
+		format  string
+		content string
+		want    string
+	}{
+		"gei without columns":  {"mapping.csv", Users, FormatGEI, "source,target\noctocat,octo_acme\n", "not a mannequin CSV"},
+		"unknown format":       {"mapping.csv", Users, "xml", "source,target\n", "unknown mapping format"},
+		"no header":            {"mapping.csv", Teams, "", "acme/platform,platform\n", "line 1: expected a header"},
+		"missing target":       {"mapping.csv", Users, "", "source,target\n\noctocat\n", "line 3: missing the target column"},
+		"conflicting source":   {"mapping.csv", Repositories, "", "source,target\nacme/api,api\nACME/api,api-v2\n", "line 3: ACME/api is mapped to api-v2 and to api on line 2"},
+		"shared target user":   {"mapping.csv", Users, "", "source,target\noctocat,octo\nhubot,octo\n", "hubot and octocat on line 2 are both mapped to octo"},
+		"json duplicate key":   {"mapping.json", Users, "", "{\n\"octocat\": \"a\",\n\"octocat\": \"b\"\n}", "line 3: octocat is mapped to b and to a on line 2"},
+		"json target type":     {"mapping.json", Users, "", `{"octocat": 1}`, "must be a string"},
+		"json list fields":     {"mapping.json", Users, "", `[{"source": "octocat"}]`, "entry 1: expected string source and target fields"},
+		"yaml duplicate key":   {"mapping.yaml", Users, "", "octocat: a\noctocat: b\n", "line 2: octocat is mapped to b and to a on line 1"},
+		"yaml not a mapping":   {"mapping.yaml", Users, "", "octocat\n", "expected a mapping"},
+		"yaml empty target":    {"mapping.yaml", Teams, "", "acme/platform: \"\"\n", "must not be empty"},
+		"malformed csv quotes": {"mapping.csv", Users, "", "source,target\n\"octocat,octo\n", "mapping file"},
+	}
+
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			_, err := Load(writeFile(t, tt.file, tt.content), tt.kind, tt.format)
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("Load() error = %v, want it to contain %q", err, tt.want)
+			}
+		})
+	}
+}
+
+func TestTeamsMayShareTarget(t *testing.T) {
+	m, err := Load(writeFile(t, "teams.csv", "source,target\nacme/web,frontend\nacme/ui,frontend\n"), Teams, "")
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	if got := m.Lookup("Acme/Web"); got != "frontend" {
+		t.Errorf("Lookup() = %s, want frontend", got)
+	}
+}
+
+func TestNilMapping(t *testing.T) {
+	var m *Mapping
+	if got := m.Lookup("octocat"); got != "octocat" || m.Len() != 0 || m.Sources() != nil {
+		t.Errorf("nil mapping mapped octocat to %s", got)
+	}
+}
+
+func TestLoadConfigured(t *testing.T) {
+	t.Cleanup(viper.Reset)
+	viper.Set("MAPPING_FILE", writeFile(t, "users.csv", "source,target\noctocat,octo_acme\n"))
+	viper.Set("REPO_MAPPING_FILE", writeFile(t, "repos.json", `{"acme/api": "api-v2"}`))
+
+	if err := LoadConfigured(); err != nil {
+		t.Fatalf("LoadConfigured() error = %v", err)
+	}
+	if got := Get(Users).Lookup("octocat"); got != "octo_acme" {
+		t.Errorf("users mapped octocat to %s, want octo_acme", got)
+	}
+	if got := Get(Repositories).Lookup("acme/api"); got != "api-v2" {
+		t.Errorf("repositories mapped acme/api to %s, want api-v2", got)
+	}
+	if Get(Teams) != nil {
+		t.Error("Get(Teams) should be nil without a team mapping file")
+	}
+
+	viper.Set("TEAM_MAPPING_FILE", writeFile(t, "teams.csv", "team,name\n"))
+	if err := LoadConfigured(); err == nil {
+		t.Error("LoadConfigured() expected an error for a team mapping file without source and target columns")
+	}
+}
+
+func TestLoadPairs(t *testing.T) {
+	m, err := LoadPairs(writeFile(t, "groups.csv", "team,group\n# Platform teams\nplatform,Platform Engineers\n"), ExternalGroups, "team")
+	if err != nil {
+		t.Fatalf("LoadPairs() error = %v", err)
+	}
+	if got := targets(m); !reflect.DeepEqual(got, map[string]string{"platform": "Platform Engineers"}) {
+		t.Errorf("LoadPairs() = %v, want platform", got)
+	}
+
+	_, err = LoadPairs(writeFile(t, "groups.csv", "aaa,111\n\nbbb\n"), IdPGroups, "source_group_id")
+	if err == nil || !strings.Contains(err.Error(), "line 3: expected a source and a target column") {
+		t.Errorf("LoadPairs() error = %v, want a missing target on line 3", err)
+	}
+}
@@ -0,0 +1,61 @@
+package mapping
+
+import (
+	"encoding/csv"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+)
+
+const (
+	ExternalGroups = "external-groups"
+	IdPGroups      = "idp-groups"
+)
+
+// LoadPairs reads a mapping file of the given kind with two CSV columns, the source and its
+// target, and an optional header whose first column is named header. Lines starting with #
+// are comments. A source that is listed twice with different targets is an error.
+func LoadPairs(filename string, kind string, header string) (*Mapping, error) {
+	file, err := os.Open(filename)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	reader := csv.NewReader(file)
+	reader.Comment = '#'
+	reader.FieldsPerRecord = -1
+	entries := make([]entry, 0)
+	for first := true; ; first = false {
+		record, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("%s mapping file %s: %w", kind, filename, err)
+		}
+		line, _ := reader.FieldPos(0)
+
+		// Skip header
+		if first && strings.EqualFold(strings.TrimSpace(record[0]), header) {
+			continue
+		}
+		if len(record) != 2 || strings.TrimSpace(record[0]) == "" || strings.TrimSpace(record[1]) == "" {
+			return nil, fmt.Errorf("%s mapping file %s: line %d: expected a source and a target column", kind, filename, line)
+		}
+		entries = append(entries, entry{source: strings.TrimSpace(record[0]), target: strings.TrimSpace(record[1]), at: fmt.Sprintf("line %d", line)})
+	}
+
+	m := &Mapping{
+		Kind:     kind,
+		Filename: filename,
+		targets:  make(map[string]string),
+		sources:  make(map[string]string),
+		at:       make(map[string]string),
+	}
+	if err := m.add(entries); err != nil {
+		return nil, fmt.Errorf("%s mapping file %s: %w", kind, filename, err)
+	}
+	return m, nil
+}
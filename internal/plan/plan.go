@@ -0,0 +1,92 @@
+package plan
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/mona-actions/gh-migrate-teams/internal/team"
+)
+
+const version = 1
+
+// Plan is a reviewable list of the changes a sync would make to the target organization
+type Plan struct {
+	Version            int               `json:"version"`
+	SourceOrganization string            `json:"source_organization"`
+	TargetOrganization string            `json:"target_organization"`
+	CreatedAt          time.Time         `json:"created_at"`
+	TargetState        map[string]string `json:"target_state"`
+	Changes            []team.Change     `json:"changes"`
+	Hash               string            `json:"hash"`
+}
+
+func New(sourceOrganization string, targetOrganization string) *Plan {
+	return &Plan{
+		Version:            version,
+		SourceOrganization: sourceOrganization,
+		TargetOrganization: targetOrganization,
+		CreatedAt:          time.Now().UTC().Truncate(time.Second),
+		TargetState:        make(map[string]string),
+		Changes:            make([]team.Change, 0),
+	}
+}
+
+// ComputeHash returns a hash of the plan contents, excluding the stored hash itself
+func (p *Plan) ComputeHash() (string, error) {
+	unhashed := *p
+	unhashed.Hash = ""
+
+	data, err := json.Marshal(unhashed)
+	if err != nil {
+		return "", err
+	}
+
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:]), nil
+}
+
+func (p *Plan) Save(filename string) error {
+	hash, err := p.ComputeHash()
+	if err != nil {
+		return err
+	}
+	p.Hash = hash
+
+	data, err := json.MarshalIndent(p, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	return os.WriteFile(filename, data, 0644)
+}
+
+// Load reads a plan file and verifies that it has not been modified since it was saved
+func Load(filename string) (*Plan, error) {
+	data, err := os.ReadFile(filename)
+	if err != nil {
+		return nil, err
+	}
+
+	var p Plan
+	if err := json.Unmarshal(data, &p); err != nil {
+		return nil, fmt.Errorf("unable to parse plan file %s: %w", filename, err)
+	}
+
+	if p.Version != version {
+		return nil, fmt.Errorf("unsupported plan version %d", p.Version)
+	}
+
+	hash, err := p.ComputeHash()
+	if err != nil {
+		return nil, err
+	}
+	if hash != p.Hash {
+		return nil, fmt.Errorf("plan file %s has been modified since it was created (hash mismatch)", filename)
+	}
+
+	return &p, nil
+}
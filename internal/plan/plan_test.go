@@ -0,0 +1,59 @@
+package plan
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/mona-actions/gh-migrate-teams/internal/team"
+)
+
+func TestSaveAndLoad(t *testing.T) {
+	filename := filepath.Join(t.TempDir(), "plan.json")
+
+	p := New("source-org", "target-org")
+	p.TargetState["team-a"] = ""
+	p.Changes = append(p.Changes,
+		team.Change{Action: team.ActionCreateTeam, Team: "team-a", Name: "Team A", Privacy: "closed"},
+		team.Change{Action: team.ActionAddMember, Team: "team-a", Member: "octocat", Role: "maintainer"},
+	)
+
+	if err := p.Save(filename); err != nil {
+		t.Fatalf("Save() error = %v", err)
+	}
+
+	loaded, err := Load(filename)
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	if loaded.Hash != p.Hash {
+		t.Errorf("Load() hash = %v, expected %v", loaded.Hash, p.Hash)
+	}
+	if len(loaded.Changes) != 2 || loaded.Changes[1].Member != "octocat" {
+		t.Errorf("Load() changes = %v, expected %v", loaded.Changes, p.Changes)
+	}
+}
+
+func TestLoadRejectsModifiedPlan(t *testing.T) {
+	filename := filepath.Join(t.TempDir(), "plan.json")
+
+	p := New("source-org", "target-org")
+	p.Changes = append(p.Changes, team.Change{Action: team.ActionAddMember, Team: "team-a", Member: "octocat", Role: "member"})
+	if err := p.Save(filename); err != nil {
+		t.Fatalf("Save() error = %v", err)
+	}
+
+	data, err := os.ReadFile(filename)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tampered := strings.Replace(string(data), "octocat", "mallory", 1)
+	if err := os.WriteFile(filename, []byte(tampered), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := Load(filename); err == nil {
+		t.Error("Load() expected an error for a modified plan")
+	}
+}
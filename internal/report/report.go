@@ -0,0 +1,299 @@
+package report
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/google/go-github/v62/github"
+)
+
+const (
+	Success          = "success"
+	AlreadyExists    = "already-exists"
+	NotFound         = "not-found"
+	ValidationFailed = "validation-failed"
+	Forbidden        = "forbidden"
+	CrossOwner       = "cross-owner"
+	Failed           = "failed"
+)
+
+// Outcomes lists every outcome in the order they are reported
+var Outcomes = []string{Success, AlreadyExists, NotFound, ValidationFailed, Forbidden, CrossOwner, Failed}
+
+// Result is the outcome of a single operation against the target organization. Operations
+// on a repository rather than a team, such as adding collaborators, set Repository instead of Team.
+type Result struct {
+	Team       string `json:"team,omitempty"`
+	Repository string `json:"repository,omitempty"`
+	Operation  string `json:"operation"`
+	Subject    string `json:"subject,omitempty"`
+	Outcome    string `json:"outcome"`
+	Error      string `json:"error,omitempty"`
+}
+
+// Invitation is a repository invitation that has not been accepted yet
+type Invitation struct {
+	Repository string `json:"repository"`
+	Login      string `json:"login"`
+	Permission string `json:"permission"`
+	CreatedAt  string `json:"created_at"`
+}
+
+// Adjustment is a change made to a team so that GitHub accepts it
+type Adjustment struct {
+	Team   string `json:"team"`
+	Change string `json:"change"`
+	Reason string `json:"reason"`
+}
+
+// TeamSummary counts the outcomes of the operations made on a team, or on a repository
+// for operations that are not made on a team
+type TeamSummary struct {
+	Team   string         `json:"team"`
+	Counts map[string]int `json:"counts"`
+}
+
+// Report is the machine-readable result of a run
+type Report struct {
+	RunId              string         `json:"run_id"`
+	SourceOrganization string         `json:"source_organization,omitempty"`
+	TargetOrganization string         `json:"target_organization"`
+	GeneratedAt        time.Time      `json:"generated_at"`
+	Totals             map[string]int `json:"totals"`
+	Teams              []TeamSummary  `json:"teams"`
+	Results            []Result       `json:"results"`
+	Invitations        []Invitation   `json:"pending_invitations"`
+	Adjustments        []Adjustment   `json:"adjustments"`
+}
+
+var (
+	mu          sync.Mutex
+	results     = make([]Result, 0)
+	invitations = make([]Invitation, 0)
+	adjustments = make([]Adjustment, 0)
+)
+
+// ErrNotFound marks an object that was found to be missing before calling the GitHub API
+var ErrNotFound = errors.New("not found")
+
+// ErrCrossOwner marks a repository grant that is not made because the repository is not owned by
+// the organization of the team
+var ErrCrossOwner = errors.New("repository is owned by another organization")
+
+// Classify maps the error returned by the GitHub API to an outcome
+func Classify(err error) string {
+	if err == nil {
+		return Success
+	}
+	if errors.Is(err, ErrNotFound) {
+		return NotFound
+	}
+	if errors.Is(err, ErrCrossOwner) {
+		return CrossOwner
+	}
+
+	message := strings.ToLower(err.Error())
+	if strings.Contains(message, "already exists") || strings.Contains(message, "must be unique") {
+		return AlreadyExists
+	}
+
+	var errorResponse *github.ErrorResponse
+	if errors.As(err, &errorResponse) && errorResponse.Response != nil {
+		switch errorResponse.Response.StatusCode {
+		case http.StatusNotFound:
+			return NotFound
+		case http.StatusForbidden:
+			return Forbidden
+		case http.StatusUnprocessableEntity:
+			for _, e := range errorResponse.Errors {
+				if e.Code == "already_exists" {
+					return AlreadyExists
+				}
+			}
+			return ValidationFailed
+		}
+	}
+	return Failed
+}
+
+// Record adds the outcome of an operation on a team to the report and returns the outcome
+func Record(team string, operation string, subject string, err error) string {
+	return add(Result{Team: team, Operation: operation, Subject: subject}, err)
+}
+
+// RecordRepository adds the outcome of an operation on a repository to the report and returns the outcome
+func RecordRepository(repository string, operation string, subject string, err error) string {
+	return add(Result{Repository: repository, Operation: operation, Subject: subject}, err)
+}
+
+// RecordInvitation adds a pending repository invitation to the report
+func RecordInvitation(invitation Invitation) {
+	mu.Lock()
+	defer mu.Unlock()
+	invitations = append(invitations, invitation)
+}
+
+// RecordAdjustment adds a change made to a team so that GitHub accepts it to the report
+func RecordAdjustment(adjustment Adjustment) {
+	mu.Lock()
+	defer mu.Unlock()
+	adjustments = append(adjustments, adjustment)
+}
+
+func add(r Result, err error) string {
+	r.Outcome = Classify(err)
+	if err != nil {
+		r.Error = err.Error()
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+	results = append(results, r)
+	return r.Outcome
+}
+
+// Results returns the operations recorded so far, in the order they were recorded
+func Results() []Result {
+	mu.Lock()
+	defer mu.Unlock()
+	return append([]Result(nil), results...)
+}
+
+// Reset discards the operations, invitations and adjustments recorded so far
+func Reset() {
+	mu.Lock()
+	defer mu.Unlock()
+	results = make([]Result, 0)
+	invitations = make([]Invitation, 0)
+	adjustments = make([]Adjustment, 0)
+}
+
+// New builds a report of the operations recorded so far
+func New(runId string, sourceOrganization string, targetOrganization string) *Report {
+	r := &Report{
+		RunId:              runId,
+		SourceOrganization: sourceOrganization,
+		TargetOrganization: targetOrganization,
+		GeneratedAt:        time.Now().UTC().Truncate(time.Second),
+		Totals:             emptyCounts(),
+		Teams:              make([]TeamSummary, 0),
+		Results:            Results(),
+	}
+
+	mu.Lock()
+	r.Invitations = append([]Invitation{}, invitations...)
+	r.Adjustments = append([]Adjustment{}, adjustments...)
+	mu.Unlock()
+
+	teams := make(map[string]int)
+	for _, result := range r.Results {
+		name := result.Team
+		if name == "" {
+			name = result.Repository
+		}
+		i, exists := teams[name]
+		if !exists {
+			i = len(r.Teams)
+			teams[name] = i
+			r.Teams = append(r.Teams, TeamSummary{Team: name, Counts: emptyCounts()})
+		}
+		r.Teams[i].Counts[result.Outcome]++
+		r.Totals[result.Outcome]++
+	}
+	sort.SliceStable(r.Teams, func(i, j int) bool { return r.Teams[i].Team < r.Teams[j].Team })
+
+	return r
+}
+
+func emptyCounts() map[string]int {
+	counts := make(map[string]int)
+	for _, outcome := range Outcomes {
+		counts[outcome] = 0
+	}
+	return counts
+}
+
+// Failures returns the number of operations that did not succeed or were already in place
+func (r *Report) Failures() int {
+	failures := 0
+	for _, outcome := range Outcomes {
+		if outcome != Success && outcome != AlreadyExists {
+			failures += r.Totals[outcome]
+		}
+	}
+	return failures
+}
+
+func (r *Report) SaveJSON(filename string) error {
+	data, err := json.MarshalIndent(r, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(filename, data, 0644)
+}
+
+// SaveCSV writes one row per operation
+func (r *Report) SaveCSV(filename string) error {
+	rows := [][]string{{"run_id", "team", "repository", "operation", "subject", "outcome", "error"}}
+	for _, result := range r.Results {
+		rows = append(rows, []string{r.RunId, result.Team, result.Repository, result.Operation, result.Subject, result.Outcome, result.Error})
+	}
+	return writeCSV(filename, rows)
+}
+
+// SaveInvitationsCSV writes one row per pending invitation
+func (r *Report) SaveInvitationsCSV(filename string) error {
+	rows := [][]string{{"repository", "login", "permission", "created_at"}}
+	for _, invitation := range r.Invitations {
+		rows = append(rows, []string{invitation.Repository, invitation.Login, invitation.Permission, invitation.CreatedAt})
+	}
+	return writeCSV(filename, rows)
+}
+
+// SaveAdjustmentsCSV writes one row per adjustment
+func (r *Report) SaveAdjustmentsCSV(filename string) error {
+	rows := [][]string{{"team", "change", "reason"}}
+	for _, adjustment := range r.Adjustments {
+		rows = append(rows, []string{adjustment.Team, adjustment.Change, adjustment.Reason})
+	}
+	return writeCSV(filename, rows)
+}
+
+func writeCSV(filename string, rows [][]string) error {
+	file, err := os.Create(filename)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	writer := csv.NewWriter(file)
+	writer.WriteAll(rows)
+	return writer.Error()
+}
+
+// TableData returns the outcome counts of each team followed by the totals
+func (r *Report) TableData() [][]string {
+	header := []string{"Team / Repository"}
+	header = append(header, Outcomes...)
+	data := [][]string{header}
+
+	row := func(name string, counts map[string]int) []string {
+		cells := []string{name}
+		for _, outcome := range Outcomes {
+			cells = append(cells, strconv.Itoa(counts[outcome]))
+		}
+		return cells
+	}
+	for _, t := range r.Teams {
+		data = append(data, row(t.Team, t.Counts))
+	}
+	return append(data, row("Total", r.Totals))
+}
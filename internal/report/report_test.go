@@ -0,0 +1,90 @@
+package report
+
+import (
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/google/go-github/v62/github"
+)
+
+func errorResponse(status int, message string, errs ...github.Error) error {
+	return &github.ErrorResponse{
+		Response: &http.Response{StatusCode: status, Request: &http.Request{Method: http.MethodPut}},
+		Message:  message,
+		Errors:   errs,
+	}
+}
+
+func TestClassify(t *testing.T) {
+	tests := []struct {
+		name string
+		err  error
+		want string
+	}{
+		{"nil error", nil, Success},
+		{"not found", errorResponse(http.StatusNotFound, "Not Found"), NotFound},
+		{"forbidden", errorResponse(http.StatusForbidden, "Resource not accessible by integration"), Forbidden},
+		{"validation failed", errorResponse(http.StatusUnprocessableEntity, "Validation Failed"), ValidationFailed},
+		{"team name taken", errorResponse(http.StatusUnprocessableEntity, "Validation Failed", github.Error{Message: "Name must be unique for this org"}), AlreadyExists},
+		{"already exists code", errorResponse(http.StatusUnprocessableEntity, "Validation Failed", github.Error{Code: "already_exists"}), AlreadyExists},
+		{"missing before the API call", fmt.Errorf("%w: external group admins", ErrNotFound), NotFound},
+		{"cross-owner grant", fmt.Errorf("%w: %w", ErrCrossOwner, errorResponse(http.StatusNotFound, "Not Found")), CrossOwner},
+		{"other error", errors.New("connection reset"), Failed},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := Classify(tt.err); got != tt.want {
+				t.Errorf("Classify() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestReport(t *testing.T) {
+	Reset()
+	defer Reset()
+
+	Record("team-b", "add-member", "user1", nil)
+	Record("team-a", "add-repository", "repo1", errorResponse(http.StatusNotFound, "Not Found"))
+	Record("team-a", "add-repository", "repo2", nil)
+	RecordRepository("acme/web", "add-collaborator", "user2", nil)
+
+	r := New("run-1", "source", "target")
+	if len(r.Teams) != 3 || r.Teams[0].Team != "acme/web" || r.Teams[1].Team != "team-a" {
+		t.Fatalf("New() teams = %+v, want acme/web, team-a and team-b in order", r.Teams)
+	}
+	if r.Teams[1].Counts[Success] != 1 || r.Teams[1].Counts[NotFound] != 1 {
+		t.Errorf("team-a counts = %v, want 1 success and 1 not-found", r.Teams[1].Counts)
+	}
+	if r.Totals[Success] != 3 || r.Failures() != 1 {
+		t.Errorf("totals = %v, failures = %d, want 3 successes and 1 failure", r.Totals, r.Failures())
+	}
+
+	data := r.TableData()
+	if len(data) != 5 || data[4][0] != "Total" || data[4][1] != "3" {
+		t.Errorf("TableData() = %v, want header, three rows and totals", data)
+	}
+
+	filename := filepath.Join(t.TempDir(), "report.csv")
+	if err := r.SaveCSV(filename); err != nil {
+		t.Fatalf("SaveCSV() error = %v", err)
+	}
+	file, err := os.Open(filename)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer file.Close()
+	records, err := csv.NewReader(file).ReadAll()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(records) != 5 || records[2][5] != NotFound || records[4][2] != "acme/web" {
+		t.Errorf("SaveCSV() wrote %v, want header and four results", records)
+	}
+}
@@ -0,0 +1,106 @@
+package repository
+
+import (
+	"strconv"
+	"strings"
+
+	"github.com/mona-actions/gh-migrate-teams/internal/api"
+)
+
+// GetSourceOrganizationRepositoryNames returns the names of the repositories in the source organization
+func GetSourceOrganizationRepositoryNames() []string {
+	data := api.GetSourceOrganizationRepositories()
+
+	names := make([]string, 0, len(data))
+	for _, repository := range data {
+		names = append(names, repository["Name"])
+	}
+
+	return names
+}
+
+// GetDirectCollaborators returns the users with direct access to a source repository
+func GetDirectCollaborators(owner string, name string) ([]Collaborator, error) {
+	data, err := api.GetSourceRepositoryCollaborators(owner, name)
+	if err != nil {
+		return nil, err
+	}
+
+	collaborators := make([]Collaborator, 0, len(data))
+	for _, collaborator := range data {
+		outside, _ := strconv.ParseBool(collaborator["Outside"])
+		collaborators = append(collaborators, Collaborator{
+			Login:      collaborator["Login"],
+			Permission: collaborator["Permission"],
+			Outside:    outside,
+		})
+	}
+
+	return collaborators, nil
+}
+
+// GetTargetCollaborators returns the users with direct access to a target repository
+func GetTargetCollaborators(name string) ([]Collaborator, error) {
+	data, err := api.GetTargetRepositoryCollaborators(name)
+	if err != nil {
+		return nil, err
+	}
+
+	collaborators := make([]Collaborator, 0, len(data))
+	for _, collaborator := range data {
+		collaborators = append(collaborators, Collaborator{Login: collaborator["Login"], Permission: collaborator["Permission"]})
+	}
+
+	return collaborators, nil
+}
+
+// GetTargetInvitations returns the pending collaborator invitations to a target repository
+func GetTargetInvitations(name string) ([]Invitation, error) {
+	data, err := api.GetTargetRepositoryInvitations(name)
+	if err != nil {
+		return nil, err
+	}
+
+	invitations := make([]Invitation, 0, len(data))
+	for _, invitation := range data {
+		invitations = append(invitations, Invitation{Login: invitation["Login"], Permission: invitation["Permission"], CreatedAt: invitation["CreatedAt"]})
+	}
+
+	return invitations, nil
+}
+
+// CollaboratorChanges compares the source collaborators with the target repository. It returns
+// the collaborators that still need to be added or given a different permission, with the
+// permission they have now in Previous, and the invitations already sent to source
+// collaborators that have not been accepted yet.
+func CollaboratorChanges(source []Collaborator, target []Collaborator, invitations []Invitation) ([]Collaborator, []Invitation) {
+	targetPermissions := make(map[string]string)
+	for _, collaborator := range target {
+		targetPermissions[strings.ToLower(collaborator.Login)] = collaborator.Permission
+	}
+	pending := make(map[string]Invitation)
+	for _, invitation := range invitations {
+		pending[strings.ToLower(invitation.Login)] = invitation
+	}
+
+	missing := make([]Collaborator, 0)
+	waiting := make([]Invitation, 0)
+	for _, collaborator := range source {
+		login := strings.ToLower(collaborator.Login)
+		if permission, exists := targetPermissions[login]; exists {
+			if permission != collaborator.Permission {
+				collaborator.Previous = permission
+				missing = append(missing, collaborator)
+			}
+			continue
+		}
+		// Sending the invitation again would only reset its expiry
+		if invitation, exists := pending[login]; exists && invitation.Permission == collaborator.Permission {
+			waiting = append(waiting, invitation)
+			continue
+		}
+		missing = append(missing, collaborator)
+	}
+
+	return missing, waiting
+}
@@ -0,0 +1,40 @@
+package repository
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestCollaboratorChanges(t *testing.T) {
+	source := []Collaborator{
+		{Login: "alice", Permission: "push"},
+		{Login: "Bob", Permission: "admin"},
+		{Login: "carol", Permission: "pull", Outside: true},
+		{Login: "dave", Permission: "maintain", Outside: true},
+		{Login: "erin", Permission: "triage", Outside: true},
+	}
+	target := []Collaborator{
+		{Login: "alice", Permission: "push"},
+		{Login: "bob", Permission: "pull"},
+	}
+	invitations := []Invitation{
+		{Login: "carol", Permission: "pull", CreatedAt: "2024-01-01T00:00:00Z"},
+		{Login: "dave", Permission: "pull", CreatedAt: "2024-01-01T00:00:00Z"},
+	}
+
+	missing, waiting := CollaboratorChanges(source, target, invitations)
+
+	wantMissing := []Collaborator{
+		{Login: "Bob", Permission: "admin", Previous: "pull"},
+		{Login: "dave", Permission: "maintain", Outside: true},
+		{Login: "erin", Permission: "triage", Outside: true},
+	}
+	if !reflect.DeepEqual(missing, wantMissing) {
+		t.Errorf("CollaboratorChanges() missing = %v, want %v", missing, wantMissing)
+	}
+
+	wantWaiting := []Invitation{{Login: "carol", Permission: "pull", CreatedAt: "2024-01-01T00:00:00Z"}}
+	if !reflect.DeepEqual(waiting, wantWaiting) {
+		t.Errorf("CollaboratorChanges() waiting = %v, want %v", waiting, wantWaiting)
+	}
+}
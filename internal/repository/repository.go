@@ -16,10 +16,21 @@ type Repository struct {
 	Collaborators []Collaborator
 }
 
+// Collaborator is a user with direct access to a repository. Previous is the permission a
+// collaborator has on the target repository when it needs to be changed.
 type Collaborator struct {
 	Login      string
 	Email      string
 	Permission string
+	Previous   string
+	Outside    bool
+}
+
+// Invitation is a pending invitation to become a collaborator on a repository
+type Invitation struct {
+	Login      string
+	Permission string
+	CreatedAt  string
 }
 
 func GetSourceOrganizationRepositories() repositories {
@@ -0,0 +1,299 @@
+package team
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/mona-actions/gh-migrate-teams/internal/api"
+	"github.com/spf13/viper"
+)
+
+const (
+	ActionCreateTeam    = "create-team"
+	ActionUpdateTeam    = "update-team"
+	ActionAddMember     = "add-member"
+	ActionRemoveMember  = "remove-member"
+	ActionAddRepository = "add-repository"
+
+	ActionSetReviewAssignment = "set-review-assignment"
+	ActionLinkExternalGroup   = "link-external-group"
+	ActionConnectIdPGroups    = "connect-idp-groups"
+
+	ActionRemoveRepository = "remove-repository"
+	ActionDeleteTeam       = "delete-team"
+)
+
+// Change is a single write against the target organization
+type Change struct {
+	Action      string `json:"action"`
+	Team        string `json:"team"`
+	Name        string `json:"name,omitempty"`
+	Description string `json:"description,omitempty"`
+	Privacy     string `json:"privacy,omitempty"`
+	ParentTeam  string `json:"parent_team,omitempty"`
+	Member      string `json:"member,omitempty"`
+	Role        string `json:"role,omitempty"`
+	Repository  string `json:"repository,omitempty"`
+	Permission  string `json:"permission,omitempty"`
+
+	NotificationSetting string            `json:"notification_setting,omitempty"`
+	ReviewAssignment    *ReviewAssignment `json:"review_assignment,omitempty"`
+	ExternalGroup       *ExternalGroup    `json:"external_group,omitempty"`
+	IdPGroups           []IdPGroup        `json:"idp_groups,omitempty"`
+}
+
+// Changes returns the writes needed to bring the target team in line with the
+// source team. A nil target means the team does not exist in the target yet.
+// authUserLogin is the user that will be added to newly created teams by GitHub.
+func (t Team) Changes(target *Team, authUserLogin string) []Change {
+	changes := make([]Change, 0)
+
+	if target == nil {
+		changes = append(changes, Change{
+			Action:      ActionCreateTeam,
+			Team:        t.Slug,
+			Name:        t.Name,
+			Description: t.Description,
+			Privacy:     t.Privacy,
+			ParentTeam:  t.ParentTeamName,
+
+			NotificationSetting: t.NotificationSetting,
+		})
+	}
+
+	if target != nil && !t.settingsMatch(target) {
+		changes = append(changes, Change{
+			Action:      ActionUpdateTeam,
+			Team:        t.Slug,
+			Name:        t.Name,
+			Description: t.Description,
+			Privacy:     strings.ToLower(t.Privacy),
+			ParentTeam:  t.ParentTeamName,
+
+			NotificationSetting: t.NotificationSetting,
+		})
+	}
+
+	// Index the current target state so that only missing access is added
+	targetRepositories := make(map[string]string)
+	targetMembers := make(map[string]string)
+	if target != nil {
+		for _, repository := range target.Repositories {
+			targetRepositories[strings.ToLower(repository.Name)] = repository.Permission
+		}
+		for _, member := range target.Members {
+			targetMembers[strings.ToLower(member.Login)] = strings.ToLower(member.Role)
+		}
+	}
+
+	for _, repository := range t.Repositories {
+		if permission, exists := targetRepositories[strings.ToLower(repository.Name)]; exists && permission == repository.Permission {
+			continue
+		}
+		// The target team is only read from the target organization, see CrossOwnerRepositories
+		if strings.Contains(TargetRepository(repository.Name), "/") {
+			continue
+		}
+		changes = append(changes, Change{
+			Action:     ActionAddRepository,
+			Team:       t.Slug,
+			Repository: repository.Name,
+			Permission: repository.Permission,
+		})
+	}
+
+	// Remove repository grants that no longer exist in the source
+	if target != nil && viper.GetBool("PRUNE") {
+		sourceRepositories := make(map[string]bool)
+		for _, repository := range t.Repositories {
+			sourceRepositories[strings.ToLower(repository.Name)] = true
+		}
+		for _, repository := range target.Repositories {
+			if !sourceRepositories[strings.ToLower(repository.Name)] {
+				changes = append(changes, Change{
+					Action:     ActionRemoveRepository,
+					Team:       t.Slug,
+					Repository: repository.Name,
+				})
+			}
+		}
+	}
+
+	// Teams connected to an external group or IdP groups get their members from the groups
+	if t.ExternalGroup != nil && (target == nil || target.ExternalGroup == nil || target.ExternalGroup.Id != t.ExternalGroup.Id) {
+		changes = append(changes, Change{
+			Action:        ActionLinkExternalGroup,
+			Team:          t.Slug,
+			ExternalGroup: t.ExternalGroup,
+		})
+	}
+	if len(t.IdPGroups) > 0 && !t.idpGroupsMatch(target) {
+		changes = append(changes, Change{
+			Action:    ActionConnectIdPGroups,
+			Team:      t.Slug,
+			IdPGroups: t.IdPGroups,
+		})
+	}
+	if !t.managedByIdP() && viper.GetString("USER_SYNC") != "disable" {
+		changes = append(changes, t.memberChanges(target, targetMembers, authUserLogin)...)
+	}
+
+	// Set after the members as excluded members need to be part of the team
+	if !t.reviewAssignmentMatches(target) {
+		changes = append(changes, Change{
+			Action:           ActionSetReviewAssignment,
+			Team:             t.Slug,
+			Name:             t.Name,
+			ReviewAssignment: t.ReviewAssignment,
+		})
+	}
+
+	return changes
+}
+
+// memberChanges returns the writes needed to bring the target team's members in line
+// with the source team, targetMembers maps the lowercase logins of the target members to their role
+func (t Team) memberChanges(target *Team, targetMembers map[string]string, authUserLogin string) []Change {
+	changes := make([]Change, 0)
+
+	memberMap := make(map[string]bool)
+	for _, member := range t.Members {
+		memberMap[strings.ToLower(member.Login)] = true
+		role := strings.ToLower(member.Role)
+		if current, exists := targetMembers[strings.ToLower(member.Login)]; exists && current == role {
+			continue
+		}
+		changes = append(changes, Change{
+			Action: ActionAddMember,
+			Team:   t.Slug,
+			Member: member.Login,
+			Role:   role,
+		})
+	}
+
+	// Remove members that are no longer part of the source team
+	if target != nil && viper.GetBool("PRUNE") && len(t.UnresolvedMembers) == 0 {
+		for _, member := range target.Members {
+			if !memberMap[strings.ToLower(member.Login)] {
+				changes = append(changes, Change{
+					Action: ActionRemoveMember,
+					Team:   t.Slug,
+					Member: member.Login,
+				})
+			}
+		}
+	}
+
+	// GitHub adds the creator of a team as a maintainer, remove them unless they belong there
+	if target == nil && authUserLogin != "" && !memberMap[strings.ToLower(authUserLogin)] {
+		changes = append(changes, Change{
+			Action: ActionRemoveMember,
+			Team:   t.Slug,
+			Member: authUserLogin,
+		})
+	}
+
+	return changes
+}
+
+// settingsMatch reports whether the target team already has the source team's
+// description, privacy, parent and notification setting
+func (t Team) settingsMatch(target *Team) bool {
+	return t.Description == target.Description &&
+		strings.EqualFold(t.Privacy, target.Privacy) &&
+		strings.EqualFold(t.ParentTeamName, target.ParentTeamName) &&
+		(t.NotificationSetting == "" || t.NotificationSetting == target.NotificationSetting)
+}
+
+// Apply performs the change against the target organization
+func (c Change) Apply() error {
+	switch c.Action {
+	case ActionCreateTeam:
+		err := api.CreateTeam(c.Name, c.Description, c.Privacy, c.ParentTeam, c.NotificationSetting)
+		// Adding a wait to account for race condition
+		time.Sleep(3 * time.Second)
+		return err
+	case ActionUpdateTeam:
+		return api.UpdateTeam(c.Team, c.Name, c.Description, c.Privacy, c.ParentTeam, c.NotificationSetting)
+	case ActionSetReviewAssignment:
+		if c.ReviewAssignment == nil {
+			return fmt.Errorf("no code review assignment for team %s", c.Team)
+		}
+		return c.ReviewAssignment.apply(c.Team)
+	case ActionLinkExternalGroup:
+		if c.ExternalGroup == nil {
+			return fmt.Errorf("no external group for team %s", c.Team)
+		}
+		return api.LinkTeamExternalGroup(c.Team, c.ExternalGroup.Id)
+	case ActionConnectIdPGroups:
+		return connectIdPGroups(c.Team, c.IdPGroups)
+	case ActionAddRepository:
+		return api.AddTeamRepository(c.Team, c.Repository, c.Permission)
+	case ActionAddMember:
+		return api.AddTeamMember(c.Team, c.Member, c.Role)
+	case ActionRemoveMember:
+		return api.RemoveTeamMember(c.Team, c.Member)
+	case ActionRemoveRepository:
+		return api.RemoveTeamRepository(c.Team, c.Repository)
+	case ActionDeleteTeam:
+		return api.DeleteTeam(c.Team)
+	}
+	return fmt.Errorf("unknown action %q", c.Action)
+}
+
+// Subject returns what the change acts on within its team, such as a member or repository
+func (c Change) Subject() string {
+	switch c.Action {
+	case ActionCreateTeam, ActionUpdateTeam, ActionSetReviewAssignment:
+		return c.Name
+	case ActionAddMember, ActionRemoveMember:
+		return c.Member
+	case ActionAddRepository, ActionRemoveRepository:
+		return c.Repository
+	case ActionLinkExternalGroup:
+		if c.ExternalGroup != nil {
+			return c.ExternalGroup.Name
+		}
+	case ActionConnectIdPGroups:
+		return idpGroupNames(c.IdPGroups)
+	}
+	return c.Team
+}
+
+func (c Change) String() string {
+	switch c.Action {
+	case ActionCreateTeam:
+		if c.ParentTeam != "" {
+			return fmt.Sprintf("create team %s (%s, parent %s)", c.Name, c.Privacy, c.ParentTeam)
+		}
+		return fmt.Sprintf("create team %s (%s)", c.Name, c.Privacy)
+	case ActionUpdateTeam:
+		if c.ParentTeam != "" {
+			return fmt.Sprintf("update team %s settings (%s, parent %s)", c.Team, c.Privacy, c.ParentTeam)
+		}
+		return fmt.Sprintf("update team %s settings (%s, no parent)", c.Team, c.Privacy)
+	case ActionSetReviewAssignment:
+		if c.ReviewAssignment == nil || !c.ReviewAssignment.Enabled {
+			return fmt.Sprintf("disable code review assignment of %s", c.Team)
+		}
+		return fmt.Sprintf("set code review assignment of %s (%s, %d members)", c.Team, c.ReviewAssignment.Algorithm, c.ReviewAssignment.MemberCount)
+	case ActionLinkExternalGroup:
+		if c.ExternalGroup != nil {
+			return fmt.Sprintf("connect %s to external group %s (%d)", c.Team, c.ExternalGroup.Name, c.ExternalGroup.Id)
+		}
+	case ActionConnectIdPGroups:
+		return fmt.Sprintf("connect %s to IdP groups %s", c.Team, idpGroupNames(c.IdPGroups))
+	case ActionAddRepository:
+		return fmt.Sprintf("grant %s %s on %s", c.Team, c.Permission, c.Repository)
+	case ActionAddMember:
+		return fmt.Sprintf("set %s as %s of %s", c.Member, c.Role, c.Team)
+	case ActionRemoveMember:
+		return fmt.Sprintf("remove %s from %s", c.Member, c.Team)
+	case ActionRemoveRepository:
+		return fmt.Sprintf("revoke %s access to %s", c.Team, c.Repository)
+	case ActionDeleteTeam:
+		return fmt.Sprintf("delete team %s", c.Team)
+	}
+	return c.Action + " " + c.Team
+}
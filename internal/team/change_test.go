@@ -0,0 +1,127 @@
+package team
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/spf13/viper"
+)
+
+func TestChanges(t *testing.T) {
+	source := Team{
+		Name:        "Team A",
+		Slug:        "team-a",
+		Description: "Source description",
+		Privacy:     "closed",
+		Members: []Member{
+			{Login: "octocat", Role: "MAINTAINER"},
+			{Login: "hubot", Role: "MEMBER"},
+		},
+		Repositories: []Repository{
+			{Name: "repo1", Permission: "push"},
+			{Name: "repo2", Permission: "pull"},
+		},
+	}
+
+	tests := []struct {
+		name     string
+		target   *Team
+		expected []Change
+	}{
+		{
+			name:   "Team missing in target",
+			target: nil,
+			expected: []Change{
+				{Action: ActionCreateTeam, Team: "team-a", Name: "Team A", Description: "Source description", Privacy: "closed"},
+				{Action: ActionAddRepository, Team: "team-a", Repository: "repo1", Permission: "push"},
+				{Action: ActionAddRepository, Team: "team-a", Repository: "repo2", Permission: "pull"},
+				{Action: ActionAddMember, Team: "team-a", Member: "octocat", Role: "maintainer"},
+				{Action: ActionAddMember, Team: "team-a", Member: "hubot", Role: "member"},
+				{Action: ActionRemoveMember, Team: "team-a", Member: "admin-user"},
+			},
+		},
+		{
+			name: "Team already in sync",
+			target: &Team{
+				Slug:        "team-a",
+				Description: "Source description",
+				Privacy:     "closed",
+				Members: []Member{
+					{Login: "Octocat", Role: "maintainer"},
+					{Login: "hubot", Role: "member"},
+				},
+				Repositories: []Repository{
+					{Name: "repo1", Permission: "push"},
+					{Name: "repo2", Permission: "pull"},
+				},
+			},
+			expected: []Change{},
+		},
+		{
+			name: "Team with drifted settings and access",
+			target: &Team{
+				Slug:           "team-a",
+				Description:    "Old description",
+				Privacy:        "closed",
+				ParentTeamName: "parent",
+				Members: []Member{
+					{Login: "octocat", Role: "member"},
+				},
+				Repositories: []Repository{
+					{Name: "repo1", Permission: "admin"},
+					{Name: "repo2", Permission: "pull"},
+				},
+			},
+			expected: []Change{
+				{Action: ActionUpdateTeam, Team: "team-a", Name: "Team A", Description: "Source description", Privacy: "closed"},
+				{Action: ActionAddRepository, Team: "team-a", Repository: "repo1", Permission: "push"},
+				{Action: ActionAddMember, Team: "team-a", Member: "octocat", Role: "maintainer"},
+				{Action: ActionAddMember, Team: "team-a", Member: "hubot", Role: "member"},
+			},
+		},
+	}
+
+	viper.Set("USER_SYNC", "all")
+	defer viper.Set("USER_SYNC", nil)
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result := source.Changes(tt.target, "admin-user")
+			if !reflect.DeepEqual(result, tt.expected) {
+				t.Errorf("Changes() = %v, expected %v", result, tt.expected)
+			}
+		})
+	}
+}
+
+func TestChangesPrune(t *testing.T) {
+	source := Team{
+		Slug:         "team-a",
+		Members:      []Member{{Login: "octocat", Role: "MEMBER"}},
+		Repositories: []Repository{{Name: "repo1", Permission: "push"}},
+	}
+	target := &Team{
+		Slug:         "team-a",
+		Members:      []Member{{Login: "octocat", Role: "member"}, {Login: "former-member", Role: "member"}},
+		Repositories: []Repository{{Name: "repo1", Permission: "push"}, {Name: "old-repo", Permission: "pull"}},
+	}
+
+	viper.Set("USER_SYNC", "all")
+	viper.Set("PRUNE", true)
+	defer viper.Set("USER_SYNC", nil)
+	defer viper.Set("PRUNE", nil)
+
+	expected := []Change{
+		{Action: ActionRemoveRepository, Team: "team-a", Repository: "old-repo"},
+		{Action: ActionRemoveMember, Team: "team-a", Member: "former-member"},
+	}
+	if result := source.Changes(target, ""); !reflect.DeepEqual(result, expected) {
+		t.Errorf("Changes() = %v, expected %v", result, expected)
+	}
+
+	// A member whose target user is not known could be any of the target members
+	source.UnresolvedMembers = []string{"mona"}
+	if result := source.Changes(target, ""); !reflect.DeepEqual(result, expected[:1]) {
+		t.Errorf("Changes() with unresolved members = %v, expected %v", result, expected[:1])
+	}
+}
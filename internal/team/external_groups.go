@@ -0,0 +1,103 @@
+package team
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/mona-actions/gh-migrate-teams/internal/api"
+	"github.com/mona-actions/gh-migrate-teams/internal/mapping"
+)
+
+// ExternalGroup is an IdP group of an Enterprise Managed Users organization. A team
+// connected to a group gets its members from the group instead of direct member adds.
+type ExternalGroup struct {
+	Id   int64  `json:"id"`
+	Name string `json:"name"`
+}
+
+// UnlinkedTeam is a team that could not be connected to an external group
+type UnlinkedTeam struct {
+	Team   string
+	Group  string
+	Reason string
+}
+
+// LoadExternalGroupMappings reads a CSV file of source team slugs or names and the external
+// group name or ID each team is connected to, with an optional team,group header
+func LoadExternalGroupMappings(filename string) (*mapping.Mapping, error) {
+	return mapping.LoadPairs(filename, mapping.ExternalGroups, "team")
+}
+
+// GetTargetExternalGroups returns the external groups of the target organization
+func GetTargetExternalGroups() ([]ExternalGroup, error) {
+	data, err := api.GetTargetExternalGroups()
+	if err != nil {
+		return nil, err
+	}
+	return externalGroups(data), nil
+}
+
+// LoadExternalGroup reads the external group connected to the target team, which is
+// only done for teams that are meant to be connected to one
+func (t *Team) LoadExternalGroup() error {
+	data, err := api.GetTargetTeamExternalGroups(t.Slug)
+	if err != nil {
+		return err
+	}
+
+	t.ExternalGroup = nil
+	if groups := externalGroups(data); len(groups) > 0 {
+		t.ExternalGroup = &groups[0]
+	}
+	return nil
+}
+
+func externalGroups(data []map[string]string) []ExternalGroup {
+	groups := make([]ExternalGroup, 0, len(data))
+	for _, group := range data {
+		id, _ := strconv.ParseInt(group["Id"], 10, 64)
+		groups = append(groups, ExternalGroup{Id: id, Name: group["Name"]})
+	}
+	return groups
+}
+
+// LinkExternalGroups sets the external group of each team mapped to one, matching teams by
+// slug or name and groups by ID or name. Teams whose group is not one of the given groups
+// are returned as unlinked. Teams without a mapping are not connected to a group, and both
+// keep their direct members.
+func (t Teams) LinkExternalGroups(mappings *mapping.Mapping, groups []ExternalGroup) (Teams, []UnlinkedTeam) {
+	byId := make(map[string]ExternalGroup)
+	byName := make(map[string][]ExternalGroup)
+	for _, group := range groups {
+		byId[strconv.FormatInt(group.Id, 10)] = group
+		name := strings.ToLower(group.Name)
+		byName[name] = append(byName[name], group)
+	}
+
+	linked := make(Teams, 0, len(t))
+	unlinked := make([]UnlinkedTeam, 0)
+	for _, team := range t {
+		group, exists := mappings.Target(team.Slug)
+		if !exists {
+			group, exists = mappings.Target(team.Name)
+		}
+
+		switch matches := byName[strings.ToLower(group)]; {
+		case !exists:
+			// Not meant to be connected to a group
+		case byId[group].Id != 0:
+			match := byId[group]
+			team.ExternalGroup = &match
+		case len(matches) == 1:
+			team.ExternalGroup = &matches[0]
+		case len(matches) > 1:
+			unlinked = append(unlinked, UnlinkedTeam{Team: team.Slug, Group: group, Reason: fmt.Sprintf("%d external groups are named %q, map the team to a group ID instead", len(matches), group)})
+		default:
+			unlinked = append(unlinked, UnlinkedTeam{Team: team.Slug, Group: group, Reason: fmt.Sprintf("external group %q does not exist in the target organization", group)})
+		}
+		linked = append(linked, team)
+	}
+
+	return linked, unlinked
+}
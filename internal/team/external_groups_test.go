@@ -0,0 +1,110 @@
+package team
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestLoadExternalGroupMappings(t *testing.T) {
+	filename := filepath.Join(t.TempDir(), "groups.csv")
+	content := "team,group\n# Platform teams\nplatform,Platform Engineers\nWeb Team, 42 \n"
+	if err := os.WriteFile(filename, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	mappings, err := LoadExternalGroupMappings(filename)
+	if err != nil {
+		t.Fatalf("LoadExternalGroupMappings() error = %v", err)
+	}
+	if group, _ := mappings.Target("Platform"); mappings.Len() != 2 || group != "Platform Engineers" {
+		t.Errorf("LoadExternalGroupMappings() = %v, want platform and web team", mappings.Sources())
+	}
+	if group, _ := mappings.Target("web team"); group != "42" {
+		t.Errorf("LoadExternalGroupMappings() maps web team to %q, want 42", group)
+	}
+
+	for name, content := range map[string]string{
+		"missing group": "platform\n",
+		"empty group":   "platform,\n",
+		"duplicate":     "platform,a\nPlatform,b\n",
+	} {
+		t.Run(name, func(t *testing.T) {
+			if err := os.WriteFile(filename, []byte(content), 0644); err != nil {
+				t.Fatal(err)
+			}
+			if _, err := LoadExternalGroupMappings(filename); err == nil {
+				t.Error("LoadExternalGroupMappings() expected an error")
+			}
+		})
+	}
+}
+
+func TestLinkExternalGroups(t *testing.T) {
+	teams := Teams{
+		{Name: "Platform", Slug: "platform"},
+		{Name: "Web Team", Slug: "web-team"},
+		{Name: "Ops", Slug: "ops"},
+		{Name: "Data", Slug: "data"},
+		{Name: "Security", Slug: "security"},
+	}
+	groups := []ExternalGroup{
+		{Id: 1, Name: "Platform Engineers"},
+		{Id: 42, Name: "Web"},
+		{Id: 7, Name: "Data"},
+		{Id: 8, Name: "data"},
+	}
+	filename := filepath.Join(t.TempDir(), "groups.csv")
+	content := "platform,platform engineers\nweb team,42\ndata,Data\nsecurity,Security Champions\n"
+	if err := os.WriteFile(filename, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+	mappings, err := LoadExternalGroupMappings(filename)
+	if err != nil {
+		t.Fatalf("LoadExternalGroupMappings() error = %v", err)
+	}
+
+	linked, unlinked := teams.LinkExternalGroups(mappings, groups)
+
+	got := make(map[string]int64)
+	for _, team := range linked {
+		if team.ExternalGroup != nil {
+			got[team.Slug] = team.ExternalGroup.Id
+		}
+	}
+	if want := map[string]int64{"platform": 1, "web-team": 42}; !reflect.DeepEqual(got, want) {
+		t.Errorf("linked groups = %v, want %v", got, want)
+	}
+	if len(linked) != len(teams) {
+		t.Errorf("LinkExternalGroups() returned %d teams, want %d", len(linked), len(teams))
+	}
+
+	unlinkedTeams := make([]string, 0, len(unlinked))
+	for _, u := range unlinked {
+		unlinkedTeams = append(unlinkedTeams, u.Team)
+	}
+	// Teams without a mapping are not reported
+	if want := []string{"data", "security"}; !reflect.DeepEqual(unlinkedTeams, want) {
+		t.Errorf("unlinked teams = %v, want %v", unlinkedTeams, want)
+	}
+}
+
+func TestExternalGroupChanges(t *testing.T) {
+	group := &ExternalGroup{Id: 1, Name: "Platform Engineers"}
+	source := Team{Slug: "platform", Name: "Platform", Privacy: "closed", Members: []Member{{Login: "octocat", Role: "member"}}, ExternalGroup: group}
+
+	changes := source.Changes(nil, "admin-user")
+	actions := make([]string, 0, len(changes))
+	for _, change := range changes {
+		actions = append(actions, change.Action)
+	}
+	if want := []string{ActionCreateTeam, ActionLinkExternalGroup}; !reflect.DeepEqual(actions, want) {
+		t.Errorf("Changes() actions = %v, want %v", actions, want)
+	}
+
+	target := &Team{Slug: "platform", Name: "Platform", Privacy: "closed", ExternalGroup: &ExternalGroup{Id: 1, Name: "Platform Engineers"}}
+	if changes := source.Changes(target, "admin-user"); len(changes) != 0 {
+		t.Errorf("Changes() for a connected team = %v, want none", changes)
+	}
+}
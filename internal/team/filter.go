@@ -0,0 +1,238 @@
+package team
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"path"
+	"regexp"
+	"strings"
+
+	"github.com/spf13/viper"
+)
+
+// Filter selects a subset of teams. Patterns match slugs or repository names and are
+// shell globs, or regular expressions when wrapped in slashes, for example /^eng-.*$/.
+// A team is selected when it matches any include pattern or is a descendant of a
+// DescendantsOf team, matches no exclude pattern, has between MinMembers and MaxMembers
+// members and has access to a repository matching any Repositories pattern. Empty
+// selectors select every team.
+type Filter struct {
+	Include       []string
+	Exclude       []string
+	DescendantsOf []string
+	MinMembers    int
+	MaxMembers    int
+	Repositories  []string
+}
+
+// LoadFilter builds the filter from the TEAM_INCLUDE, TEAM_EXCLUDE, TEAM_LIST_FILE,
+// TEAM_DESCENDANTS_OF, TEAM_MIN_MEMBERS, TEAM_MAX_MEMBERS and TEAM_REPOSITORY settings.
+// Comma separated values are accepted for patterns and team slugs.
+func LoadFilter() (*Filter, error) {
+	f := &Filter{
+		Include:       splitList(viper.GetString("TEAM_INCLUDE")),
+		Exclude:       splitList(viper.GetString("TEAM_EXCLUDE")),
+		DescendantsOf: splitList(viper.GetString("TEAM_DESCENDANTS_OF")),
+		MinMembers:    viper.GetInt("TEAM_MIN_MEMBERS"),
+		MaxMembers:    viper.GetInt("TEAM_MAX_MEMBERS"),
+		Repositories:  splitList(viper.GetString("TEAM_REPOSITORY")),
+	}
+
+	if filename := viper.GetString("TEAM_LIST_FILE"); filename != "" {
+		slugs, err := readTeamList(filename)
+		if err != nil {
+			return nil, fmt.Errorf("unable to read team list %s: %w", filename, err)
+		}
+		if len(slugs) == 0 {
+			return nil, fmt.Errorf("team list %s is empty", filename)
+		}
+		f.Include = append(f.Include, slugs...)
+	}
+
+	if err := f.Validate(); err != nil {
+		return nil, err
+	}
+	return f, nil
+}
+
+func splitList(value string) []string {
+	items := make([]string, 0)
+	for _, item := range strings.Split(value, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
+
+// readTeamList reads one team slug or pattern per line, ignoring blank lines and comments
+func readTeamList(filename string) ([]string, error) {
+	file, err := os.Open(filename)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	slugs := make([]string, 0)
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		slugs = append(slugs, line)
+	}
+	return slugs, scanner.Err()
+}
+
+// Validate checks that every pattern can be compiled and the member bounds are consistent
+func (f *Filter) Validate() error {
+	for _, patterns := range [][]string{f.Include, f.Exclude, f.Repositories} {
+		for _, pattern := range patterns {
+			if _, err := matchPattern(pattern, ""); err != nil {
+				return fmt.Errorf("invalid team filter pattern %q: %w", pattern, err)
+			}
+		}
+	}
+	if f.MinMembers < 0 || f.MaxMembers < 0 {
+		return fmt.Errorf("member bounds must not be negative")
+	}
+	if f.MaxMembers > 0 && f.MinMembers > f.MaxMembers {
+		return fmt.Errorf("minimum members %d is greater than maximum members %d", f.MinMembers, f.MaxMembers)
+	}
+	return nil
+}
+
+// matchPattern matches a value against a glob, or a regular expression wrapped in slashes.
+// Matching is case-insensitive, as slugs and repository names are.
+func matchPattern(pattern string, value string) (bool, error) {
+	if len(pattern) > 1 && strings.HasPrefix(pattern, "/") && strings.HasSuffix(pattern, "/") {
+		re, err := regexp.Compile("(?i)" + pattern[1:len(pattern)-1])
+		if err != nil {
+			return false, err
+		}
+		return re.MatchString(value), nil
+	}
+	return path.Match(strings.ToLower(pattern), strings.ToLower(value))
+}
+
+func matchAny(patterns []string, value string) bool {
+	for _, pattern := range patterns {
+		if matched, _ := matchPattern(pattern, value); matched {
+			return true
+		}
+	}
+	return false
+}
+
+// IsEmpty reports whether the filter selects every team
+func (f *Filter) IsEmpty() bool {
+	return f == nil || f.String() == ""
+}
+
+// String describes the selectors that are set, such as include eng-*; min members 2, in a
+// stable order. It is empty when the filter selects every team.
+func (f *Filter) String() string {
+	if f == nil {
+		return ""
+	}
+	parts := make([]string, 0)
+	for _, selector := range []struct {
+		name     string
+		patterns []string
+	}{
+		{"include", f.Include},
+		{"exclude", f.Exclude},
+		{"descendants of", f.DescendantsOf},
+		{"repositories", f.Repositories},
+	} {
+		if len(selector.patterns) > 0 {
+			parts = append(parts, selector.name+" "+strings.Join(selector.patterns, ","))
+		}
+	}
+	if f.MinMembers > 0 {
+		parts = append(parts, fmt.Sprintf("min members %d", f.MinMembers))
+	}
+	if f.MaxMembers > 0 {
+		parts = append(parts, fmt.Sprintf("max members %d", f.MaxMembers))
+	}
+	return strings.Join(parts, "; ")
+}
+
+// SelectsByContents reports whether the filter depends on team members or repositories
+func (f *Filter) SelectsByContents() bool {
+	return f != nil && (f.MinMembers > 0 || f.MaxMembers > 0 || len(f.Repositories) > 0)
+}
+
+// SelectByName applies the selectors that only need team slugs and parents. The teams
+// should include every team the descendants of a team could be found through.
+func (f *Filter) SelectByName(teams Teams) Teams {
+	if f == nil {
+		return teams
+	}
+
+	parents := make(map[string]string)
+	for _, team := range teams {
+		parents[strings.ToLower(team.Slug)] = strings.ToLower(team.ParentTeamName)
+	}
+	ancestors := make(map[string]bool)
+	for _, slug := range f.DescendantsOf {
+		ancestors[strings.ToLower(slug)] = true
+	}
+
+	// A team is a descendant when it or any of its parents is one of the ancestors
+	isDescendant := func(slug string) bool {
+		seen := make(map[string]bool)
+		for slug = strings.ToLower(slug); slug != "" && !seen[slug]; slug = parents[slug] {
+			if ancestors[slug] {
+				return true
+			}
+			seen[slug] = true
+		}
+		return false
+	}
+
+	selectAll := len(f.Include) == 0 && len(f.DescendantsOf) == 0
+	selected := make(Teams, 0, len(teams))
+	for _, team := range teams {
+		included := selectAll || matchAny(f.Include, team.Slug) || (len(ancestors) > 0 && isDescendant(team.Slug))
+		if included && !matchAny(f.Exclude, team.Slug) {
+			selected = append(selected, team)
+		}
+	}
+	return selected
+}
+
+// SelectByContents applies the selectors that need the members and repositories of each team
+func (f *Filter) SelectByContents(teams Teams) Teams {
+	if !f.SelectsByContents() {
+		return teams
+	}
+
+	selected := make(Teams, 0, len(teams))
+	for _, team := range teams {
+		if len(team.Members) < f.MinMembers || (f.MaxMembers > 0 && len(team.Members) > f.MaxMembers) {
+			continue
+		}
+		if len(f.Repositories) > 0 && !team.hasRepository(f.Repositories) {
+			continue
+		}
+		selected = append(selected, team)
+	}
+	return selected
+}
+
+// Select applies every selector of the filter
+func (f *Filter) Select(teams Teams) Teams {
+	return f.SelectByContents(f.SelectByName(teams))
+}
+
+func (t Team) hasRepository(patterns []string) bool {
+	for _, repository := range t.Repositories {
+		if matchAny(patterns, repository.Name) {
+			return true
+		}
+	}
+	return false
+}
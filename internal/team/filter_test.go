@@ -0,0 +1,76 @@
+package team
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestFilterSelect(t *testing.T) {
+	teams := Teams{
+		{Slug: "eng", Members: []Member{{Login: "a"}, {Login: "b"}, {Login: "c"}}},
+		{Slug: "eng-web", ParentTeamName: "eng", Members: []Member{{Login: "a"}}, Repositories: []Repository{{Name: "web-app"}}},
+		{Slug: "eng-web-oncall", ParentTeamName: "eng-web", Members: []Member{{Login: "b"}, {Login: "c"}}},
+		{Slug: "sales", Members: []Member{{Login: "d"}}, Repositories: []Repository{{Name: "crm"}}},
+		{Slug: "Legacy_Ops"},
+	}
+
+	tests := []struct {
+		name   string
+		filter *Filter
+		want   []string
+	}{
+		{"no filter", nil, []string{"eng", "eng-web", "eng-web-oncall", "sales", "Legacy_Ops"}},
+		{"empty filter", &Filter{}, []string{"eng", "eng-web", "eng-web-oncall", "sales", "Legacy_Ops"}},
+		{"glob include", &Filter{Include: []string{"eng-*"}}, []string{"eng-web", "eng-web-oncall"}},
+		{"regex include is case-insensitive", &Filter{Include: []string{"/^legacy_/"}}, []string{"Legacy_Ops"}},
+		{"exclude", &Filter{Exclude: []string{"eng*", "legacy_ops"}}, []string{"sales"}},
+		{"descendants of", &Filter{DescendantsOf: []string{"eng-web"}}, []string{"eng-web", "eng-web-oncall"}},
+		{"descendants or include", &Filter{DescendantsOf: []string{"eng-web"}, Include: []string{"sales"}}, []string{"eng-web", "eng-web-oncall", "sales"}},
+		{"descendants with exclude", &Filter{DescendantsOf: []string{"eng"}, Exclude: []string{"*-oncall"}}, []string{"eng", "eng-web"}},
+		{"min members", &Filter{MinMembers: 2}, []string{"eng", "eng-web-oncall"}},
+		{"max members", &Filter{MaxMembers: 1}, []string{"eng-web", "sales", "Legacy_Ops"}},
+		{"repository pattern", &Filter{Repositories: []string{"web-*", "/^crm$/"}}, []string{"eng-web", "sales"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := slugs(tt.filter.Select(teams)); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Select() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestFilterValidate(t *testing.T) {
+	invalid := []*Filter{
+		{Include: []string{"/(/"}},
+		{Exclude: []string{"[a-"}},
+		{MinMembers: 5, MaxMembers: 2},
+	}
+	for _, f := range invalid {
+		if err := f.Validate(); err == nil {
+			t.Errorf("Validate(%+v) expected an error", f)
+		}
+	}
+}
+
+func TestFilterString(t *testing.T) {
+	tests := []struct {
+		filter *Filter
+		want   string
+	}{
+		{nil, ""},
+		{&Filter{}, ""},
+		{&Filter{Include: []string{"eng-*", "sales"}, MinMembers: 2}, "include eng-*,sales; min members 2"},
+		{&Filter{Exclude: []string{"legacy"}, Repositories: []string{"/^web/"}, MaxMembers: 10}, "exclude legacy; repositories /^web/; max members 10"},
+	}
+
+	for _, tt := range tests {
+		if got := tt.filter.String(); got != tt.want {
+			t.Errorf("String() = %q, want %q", got, tt.want)
+		}
+		if got := tt.filter.IsEmpty(); got != (tt.want == "") {
+			t.Errorf("IsEmpty() = %v for %q", got, tt.want)
+		}
+	}
+}
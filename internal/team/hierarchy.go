@@ -0,0 +1,84 @@
+package team
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/mona-actions/gh-migrate-teams/internal/api"
+)
+
+// SortByHierarchy orders teams so that every parent team comes before its children,
+// keeping the original order otherwise. Parents that are not part of the teams are
+// returned so they can be reported, and a cycle in the hierarchy is returned as an error.
+func (t Teams) SortByHierarchy() (Teams, []string, error) {
+	index := make(map[string]int)
+	for i, team := range t {
+		index[strings.ToLower(team.Slug)] = i
+	}
+
+	const (
+		visiting = 1
+		visited  = 2
+	)
+	state := make(map[string]int)
+	sorted := make(Teams, 0, len(t))
+	missing := make([]string, 0)
+	seenMissing := make(map[string]bool)
+	path := make([]string, 0)
+
+	var visit func(i int) error
+	visit = func(i int) error {
+		slug := strings.ToLower(t[i].Slug)
+		switch state[slug] {
+		case visited:
+			return nil
+		case visiting:
+			return fmt.Errorf("team hierarchy contains a cycle: %s -> %s", strings.Join(path, " -> "), t[i].Slug)
+		}
+
+		state[slug] = visiting
+		path = append(path, t[i].Slug)
+		if parent := strings.ToLower(t[i].ParentTeamName); parent != "" {
+			if j, exists := index[parent]; exists {
+				if err := visit(j); err != nil {
+					return err
+				}
+			} else if !seenMissing[parent] {
+				seenMissing[parent] = true
+				missing = append(missing, t[i].ParentTeamName)
+			}
+		}
+		path = path[:len(path)-1]
+		state[slug] = visited
+
+		sorted = append(sorted, t[i])
+		return nil
+	}
+
+	for i := range t {
+		if err := visit(i); err != nil {
+			return nil, nil, err
+		}
+	}
+
+	return sorted, missing, nil
+}
+
+// EnsureParent sets the parent of the team in the target organization when it has
+// none, for example because the parent did not exist yet when the team was created
+func (t Team) EnsureParent() error {
+	if t.ParentTeamName == "" {
+		return nil
+	}
+
+	data, err := api.GetTargetTeam(t.Slug)
+	if err != nil {
+		return err
+	}
+	// Teams that already have a parent are left alone, reconcile corrects a different parent
+	if data == nil || data["ParentTeamName"] != "" {
+		return nil
+	}
+
+	return api.SetTeamParent(t.Slug, data["Name"], t.ParentTeamName)
+}
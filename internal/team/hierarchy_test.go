@@ -0,0 +1,85 @@
+package team
+
+import (
+	"reflect"
+	"testing"
+)
+
+func slugs(teams Teams) []string {
+	result := make([]string, 0, len(teams))
+	for _, team := range teams {
+		result = append(result, team.Slug)
+	}
+	return result
+}
+
+func TestSortByHierarchy(t *testing.T) {
+	tests := []struct {
+		name            string
+		teams           Teams
+		expectedOrder   []string
+		expectedMissing []string
+		expectError     bool
+	}{
+		{
+			name: "Children listed before parents",
+			teams: Teams{
+				{Slug: "grandchild", ParentTeamName: "child"},
+				{Slug: "child", ParentTeamName: "root"},
+				{Slug: "other"},
+				{Slug: "root"},
+			},
+			expectedOrder:   []string{"root", "child", "grandchild", "other"},
+			expectedMissing: []string{},
+		},
+		{
+			name: "Already ordered",
+			teams: Teams{
+				{Slug: "root"},
+				{Slug: "child-a", ParentTeamName: "root"},
+				{Slug: "child-b", ParentTeamName: "root"},
+			},
+			expectedOrder:   []string{"root", "child-a", "child-b"},
+			expectedMissing: []string{},
+		},
+		{
+			name: "Parent outside of the teams",
+			teams: Teams{
+				{Slug: "child-a", ParentTeamName: "external"},
+				{Slug: "child-b", ParentTeamName: "External"},
+			},
+			expectedOrder:   []string{"child-a", "child-b"},
+			expectedMissing: []string{"external"},
+		},
+		{
+			name: "Cycle",
+			teams: Teams{
+				{Slug: "a", ParentTeamName: "b"},
+				{Slug: "b", ParentTeamName: "c"},
+				{Slug: "c", ParentTeamName: "a"},
+			},
+			expectError: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sorted, missing, err := tt.teams.SortByHierarchy()
+			if tt.expectError {
+				if err == nil {
+					t.Error("SortByHierarchy() expected a cycle error")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("SortByHierarchy() error = %v", err)
+			}
+			if order := slugs(sorted); !reflect.DeepEqual(order, tt.expectedOrder) {
+				t.Errorf("SortByHierarchy() order = %v, expected %v", order, tt.expectedOrder)
+			}
+			if !reflect.DeepEqual(missing, tt.expectedMissing) {
+				t.Errorf("SortByHierarchy() missing = %v, expected %v", missing, tt.expectedMissing)
+			}
+		})
+	}
+}
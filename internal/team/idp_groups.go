@@ -0,0 +1,158 @@
+package team
+
+import (
+	"fmt"
+	"log"
+	"sort"
+	"strings"
+
+	"github.com/mona-actions/gh-migrate-teams/internal/api"
+	"github.com/mona-actions/gh-migrate-teams/internal/mapping"
+)
+
+// IdPGroup is an identity provider group connected to a team through team synchronization.
+// GitHub manages the members of a team connected to IdP groups.
+type IdPGroup struct {
+	Id          string `json:"id"`
+	Name        string `json:"name"`
+	Description string `json:"description,omitempty"`
+}
+
+// UnmappedIdPGroup is a source IdP group that has no counterpart in the target organization
+type UnmappedIdPGroup struct {
+	Team   string
+	Group  string
+	Reason string
+}
+
+func getTeamIdPGroups(team string) []IdPGroup {
+	data, err := api.GetSourceTeamIdPGroups(team)
+	if err != nil {
+		log.Println("Unable to get IdP groups of team", team, "-", err)
+		return nil
+	}
+	return idpGroups(data)
+}
+
+func idpGroups(data []map[string]string) []IdPGroup {
+	groups := make([]IdPGroup, 0, len(data))
+	for _, group := range data {
+		groups = append(groups, IdPGroup{Id: group["Id"], Name: group["Name"], Description: group["Description"]})
+	}
+	return groups
+}
+
+// GetTargetIdPGroups returns the IdP groups available to teams in the target organization
+func GetTargetIdPGroups() ([]IdPGroup, error) {
+	data, err := api.GetTargetIdPGroups()
+	if err != nil {
+		return nil, err
+	}
+	return idpGroups(data), nil
+}
+
+// LoadIdPGroups reads the IdP groups connected to the target team, which is only done
+// for teams that are meant to be connected to some
+func (t *Team) LoadIdPGroups() error {
+	data, err := api.GetTargetTeamIdPGroups(t.Slug)
+	if err != nil {
+		return err
+	}
+	t.IdPGroups = idpGroups(data)
+	return nil
+}
+
+// LoadIdPGroupMappings reads a CSV file of source IdP group IDs and the ID of the same group
+// in the target IdP tenant, with an optional source_group_id,target_group_id header
+func LoadIdPGroupMappings(filename string) (*mapping.Mapping, error) {
+	return mapping.LoadPairs(filename, mapping.IdPGroups, "source_group_id")
+}
+
+// MapIdPGroups translates the IdP groups of each team to the groups of the target organization.
+// Groups without a mapping keep their ID, and groups that are not available in the target
+// organization are dropped from the team and returned as unmapped.
+func (t Teams) MapIdPGroups(mappings *mapping.Mapping, targetGroups []IdPGroup) (Teams, []UnmappedIdPGroup) {
+	available := make(map[string]IdPGroup)
+	for _, group := range targetGroups {
+		available[group.Id] = group
+	}
+
+	mapped := make(Teams, 0, len(t))
+	unmapped := make([]UnmappedIdPGroup, 0)
+	for _, team := range t {
+		if len(team.IdPGroups) == 0 {
+			mapped = append(mapped, team)
+			continue
+		}
+
+		groups := make([]IdPGroup, 0, len(team.IdPGroups))
+		for _, group := range team.IdPGroups {
+			id := group.Id
+			if target, exists := mappings.Target(id); exists {
+				id = target
+			}
+			target, exists := available[id]
+			if !exists {
+				unmapped = append(unmapped, UnmappedIdPGroup{Team: team.Slug, Group: group.Name, Reason: fmt.Sprintf("IdP group %s (%s) is not available in the target organization", group.Name, id)})
+				continue
+			}
+			groups = append(groups, target)
+		}
+		team.IdPGroups = groups
+		mapped = append(mapped, team)
+	}
+
+	return mapped, unmapped
+}
+
+// idpGroupsMatch reports whether the target team is connected to the same IdP groups as the source team
+func (t Team) idpGroupsMatch(target *Team) bool {
+	if target == nil || len(t.IdPGroups) != len(target.IdPGroups) {
+		return false
+	}
+	return strings.Join(idpGroupIds(t.IdPGroups), ",") == strings.Join(idpGroupIds(target.IdPGroups), ",")
+}
+
+func idpGroupIds(groups []IdPGroup) []string {
+	ids := make([]string, 0, len(groups))
+	for _, group := range groups {
+		ids = append(ids, group.Id)
+	}
+	sort.Strings(ids)
+	return ids
+}
+
+func idpGroupNames(groups []IdPGroup) string {
+	names := make([]string, 0, len(groups))
+	for _, group := range groups {
+		names = append(names, group.Name)
+	}
+	return strings.Join(names, ", ")
+}
+
+// connectIdPGroups replaces the IdP groups connected to the target team
+func connectIdPGroups(slug string, groups []IdPGroup) error {
+	data := make([]map[string]string, 0, len(groups))
+	for _, group := range groups {
+		data = append(data, map[string]string{"Id": group.Id, "Name": group.Name, "Description": group.Description})
+	}
+	return api.SetTeamIdPGroups(slug, data)
+}
+
+// managedByIdP reports whether GitHub manages the members of the team from an external
+// group or IdP groups, in which case members are not added directly
+func (t Team) managedByIdP() bool {
+	return t.ExternalGroup != nil || len(t.IdPGroups) > 0
+}
+
+// ExportIdPGroups lists the IdP groups connected to each team as team name, group ID, group name and description
+func (t Teams) ExportIdPGroups() [][]string {
+	groups := make([][]string, 0)
+	for _, team := range t {
+		for _, group := range team.IdPGroups {
+			groups = append(groups, []string{team.Name, group.Id, group.Name, group.Description})
+		}
+	}
+
+	return groups
+}
@@ -0,0 +1,101 @@
+package team
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestLoadIdPGroupMappings(t *testing.T) {
+	filename := filepath.Join(t.TempDir(), "idp-groups.csv")
+	if err := os.WriteFile(filename, []byte("source_group_id,target_group_id\naaa, 111\nbbb,222\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	mappings, err := LoadIdPGroupMappings(filename)
+	if err != nil {
+		t.Fatalf("LoadIdPGroupMappings() error = %v", err)
+	}
+	if want := []string{"aaa", "bbb"}; !reflect.DeepEqual(mappings.Sources(), want) {
+		t.Errorf("LoadIdPGroupMappings() = %v, want %v", mappings.Sources(), want)
+	}
+	if target, _ := mappings.Target("aaa"); target != "111" {
+		t.Errorf("LoadIdPGroupMappings() maps aaa to %q, want 111", target)
+	}
+
+	for name, content := range map[string]string{
+		"missing target": "aaa\n",
+		"duplicate":      "aaa,111\naaa,222\n",
+	} {
+		t.Run(name, func(t *testing.T) {
+			if err := os.WriteFile(filename, []byte(content), 0644); err != nil {
+				t.Fatal(err)
+			}
+			if _, err := LoadIdPGroupMappings(filename); err == nil {
+				t.Error("LoadIdPGroupMappings() expected an error")
+			}
+		})
+	}
+}
+
+func TestMapIdPGroups(t *testing.T) {
+	teams := Teams{
+		{Slug: "platform", IdPGroups: []IdPGroup{{Id: "aaa", Name: "Platform"}, {Id: "ccc", Name: "Gone"}}},
+		{Slug: "web", IdPGroups: []IdPGroup{{Id: "222", Name: "Web"}}},
+		{Slug: "ops"},
+	}
+	targetGroups := []IdPGroup{
+		{Id: "111", Name: "Platform Engineers", Description: "Target tenant"},
+		{Id: "222", Name: "Web"},
+	}
+
+	filename := filepath.Join(t.TempDir(), "idp-groups.csv")
+	if err := os.WriteFile(filename, []byte("aaa,111\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	mappings, err := LoadIdPGroupMappings(filename)
+	if err != nil {
+		t.Fatalf("LoadIdPGroupMappings() error = %v", err)
+	}
+
+	mapped, unmapped := teams.MapIdPGroups(mappings, targetGroups)
+
+	want := map[string][]IdPGroup{
+		"platform": {{Id: "111", Name: "Platform Engineers", Description: "Target tenant"}},
+		"web":      {{Id: "222", Name: "Web"}},
+		"ops":      nil,
+	}
+	for _, team := range mapped {
+		if !reflect.DeepEqual(team.IdPGroups, want[team.Slug]) {
+			t.Errorf("IdPGroups of %s = %v, want %v", team.Slug, team.IdPGroups, want[team.Slug])
+		}
+	}
+	if len(unmapped) != 1 || unmapped[0].Team != "platform" || unmapped[0].Group != "Gone" {
+		t.Errorf("unmapped = %v, want the Gone group of platform", unmapped)
+	}
+}
+
+func TestIdPGroupChanges(t *testing.T) {
+	source := Team{
+		Slug:      "platform",
+		Privacy:   "closed",
+		Members:   []Member{{Login: "octocat", Role: "member"}},
+		IdPGroups: []IdPGroup{{Id: "1", Name: "Platform"}, {Id: "2", Name: "SRE"}},
+	}
+
+	changes := source.Changes(nil, "admin-user")
+	if len(changes) != 2 || changes[1].Action != ActionConnectIdPGroups || !reflect.DeepEqual(changes[1].IdPGroups, source.IdPGroups) {
+		t.Errorf("Changes() = %v, want a create-team and connect-idp-groups change", changes)
+	}
+
+	target := &Team{Slug: "platform", Privacy: "closed", IdPGroups: []IdPGroup{{Id: "2", Name: "SRE"}, {Id: "1", Name: "Platform"}}}
+	if changes := source.Changes(target, ""); len(changes) != 0 {
+		t.Errorf("Changes() for a connected team = %v, want none", changes)
+	}
+
+	target.IdPGroups = target.IdPGroups[:1]
+	if changes := source.Changes(target, ""); len(changes) != 1 || changes[0].Action != ActionConnectIdPGroups {
+		t.Errorf("Changes() for a partly connected team = %v, want a connect-idp-groups change", changes)
+	}
+}
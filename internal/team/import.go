@@ -0,0 +1,158 @@
+package team
+
+import (
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"os"
+	"regexp"
+	"strings"
+)
+
+// ExportTeams lists the settings of each team as name, slug, description, privacy, parent team slug,
+// notification setting and code review assignment (enabled, algorithm, member count, notify team
+// and excluded members separated by semicolons)
+func (t Teams) ExportTeams() [][]string {
+	teams := make([][]string, 0, len(t))
+	for _, team := range t {
+		record := []string{team.Name, team.Slug, team.Description, strings.ToLower(team.Privacy), team.ParentTeamName}
+		teams = append(teams, append(record, team.settingsColumns()...))
+	}
+
+	return teams
+}
+
+// ReadTeamCSVs recreates teams from the CSV files written by export with the given prefix.
+// <prefix>-teams.csv and <prefix>-team-idp-groups.csv are optional, teams that are only named in the membership or repository
+// files are created as closed teams without a parent, with a slug derived from their name.
+func ReadTeamCSVs(prefix string) (Teams, error) {
+	teams := make(Teams, 0)
+	index := make(map[string]int)
+	// teamIndex returns the position of the team with the given name, adding it if needed
+	teamIndex := func(name string) int {
+		i, exists := index[name]
+		if !exists {
+			i = len(teams)
+			index[name] = i
+			teams = append(teams, Team{Name: name, Slug: Slugify(name), Privacy: "closed", Members: []Member{}, Repositories: []Repository{}})
+		}
+		return i
+	}
+
+	settings, err := readCSV(prefix+"-teams.csv", 5, true)
+	if err != nil {
+		return nil, err
+	}
+	for i, record := range settings {
+		privacy := strings.ToLower(record[3])
+		if privacy == "" {
+			privacy = "closed"
+		}
+		if privacy != "closed" && privacy != "secret" {
+			return nil, fmt.Errorf("%s-teams.csv line %d: privacy must be closed or secret, got %q", prefix, i+1, record[3])
+		}
+		if _, exists := index[record[0]]; exists {
+			return nil, fmt.Errorf("%s-teams.csv line %d: team %q is listed more than once", prefix, i+1, record[0])
+		}
+
+		t := &teams[teamIndex(record[0])]
+		if record[1] != "" {
+			t.Slug = record[1]
+		}
+		t.Description = record[2]
+		t.Privacy = privacy
+		t.ParentTeamName = record[4]
+		if err := t.parseSettingsColumns(record[5:]); err != nil {
+			return nil, fmt.Errorf("%s-teams.csv line %d: %w", prefix, i+1, err)
+		}
+	}
+
+	memberships, err := readCSV(prefix+"-team-membership.csv", 3, false)
+	if err != nil {
+		return nil, err
+	}
+	for _, record := range memberships {
+		// The role column was added after the first exports were made
+		role := "member"
+		if len(record) > 3 && record[3] != "" {
+			role = strings.ToLower(record[3])
+		}
+		t := &teams[teamIndex(record[0])]
+		t.Members = append(t.Members, Member{Login: record[1], Email: record[2], Role: role})
+	}
+
+	repositories, err := readCSV(prefix+"-team-repositories.csv", 3, false)
+	if err != nil {
+		return nil, err
+	}
+	for _, record := range repositories {
+		t := &teams[teamIndex(record[0])]
+		t.Repositories = append(t.Repositories, Repository{Name: TargetRepository(record[1]), Permission: normalizePermission(record[2])})
+	}
+
+	groups, err := readCSV(prefix+"-team-idp-groups.csv", 3, true)
+	if err != nil {
+		return nil, err
+	}
+	for _, record := range groups {
+		description := ""
+		if len(record) > 3 {
+			description = record[3]
+		}
+		t := &teams[teamIndex(record[0])]
+		t.IdPGroups = append(t.IdPGroups, IdPGroup{Id: record[1], Name: record[2], Description: description})
+	}
+
+	if len(teams) == 0 {
+		return nil, fmt.Errorf("no teams found in the CSV files with prefix %s", prefix)
+	}
+
+	return teams, nil
+}
+
+// readCSV reads the records of a file written by export, which has no header. Records
+// need at least the given number of fields, and an optional file may be missing.
+func readCSV(filename string, fields int, optional bool) ([][]string, error) {
+	file, err := os.Open(filename)
+	if err != nil {
+		if optional && errors.Is(err, os.ErrNotExist) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	defer file.Close()
+
+	reader := csv.NewReader(file)
+	reader.FieldsPerRecord = -1 // Allow variable number of fields per record
+	records, err := reader.ReadAll()
+	if err != nil {
+		return nil, fmt.Errorf("unable to parse %s: %w", filename, err)
+	}
+
+	result := make([][]string, 0, len(records))
+	for i, record := range records {
+		// Skip blank rows left behind by spreadsheet editors
+		if strings.TrimSpace(strings.Join(record, "")) == "" {
+			continue
+		}
+		if len(record) < fields {
+			return nil, fmt.Errorf("%s line %d: expected at least %d fields, got %d", filename, i+1, fields, len(record))
+		}
+		for j := range record {
+			record[j] = strings.TrimSpace(record[j])
+		}
+		if record[0] == "" {
+			return nil, fmt.Errorf("%s line %d: team name is empty", filename, i+1)
+		}
+		result = append(result, record)
+	}
+
+	return result, nil
+}
+
+var nonSlugCharacters = regexp.MustCompile(`[^a-z0-9_]+`)
+
+// Slugify derives the slug GitHub gives a team with the given name
+func Slugify(name string) string {
+	return strings.Trim(nonSlugCharacters.ReplaceAllString(strings.ToLower(name), "-"), "-")
+}
@@ -0,0 +1,64 @@
+package team
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestReadTeamCSVs(t *testing.T) {
+	prefix := filepath.Join(t.TempDir(), "acme")
+	files := map[string]string{
+		"-teams.csv":             "Platform Team,platform,Runs the platform,secret,\nWeb,web,,closed,platform\n",
+		"-team-membership.csv":   "Platform Team,alice,alice@example.com,maintainer\nWeb,bob,bob@example.com\n\nOps & Infra,carol,,member\n",
+		"-team-repositories.csv": "Web,web-app,push\nOps & Infra,terraform,admin\n",
+		"-team-idp-groups.csv":   "Platform Team,abc-123,Platform Engineers,All platform engineers\n",
+	}
+	for suffix, content := range files {
+		if err := os.WriteFile(prefix+suffix, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	teams, err := ReadTeamCSVs(prefix)
+	if err != nil {
+		t.Fatalf("ReadTeamCSVs() error = %v", err)
+	}
+
+	want := Teams{
+		{Name: "Platform Team", Slug: "platform", Description: "Runs the platform", Privacy: "secret",
+			Members: []Member{{Login: "alice", Email: "alice@example.com", Role: "maintainer"}}, Repositories: []Repository{},
+			IdPGroups: []IdPGroup{{Id: "abc-123", Name: "Platform Engineers", Description: "All platform engineers"}}},
+		{Name: "Web", Slug: "web", Privacy: "closed", ParentTeamName: "platform",
+			Members: []Member{{Login: "bob", Email: "bob@example.com", Role: "member"}}, Repositories: []Repository{{Name: "web-app", Permission: "push"}}},
+		{Name: "Ops & Infra", Slug: "ops-infra", Privacy: "closed",
+			Members: []Member{{Login: "carol", Role: "member"}}, Repositories: []Repository{{Name: "terraform", Permission: "admin"}}},
+	}
+	if !reflect.DeepEqual(teams, want) {
+		t.Errorf("ReadTeamCSVs() =\n%+v\nwant\n%+v", teams, want)
+	}
+}
+
+func TestReadTeamCSVsErrors(t *testing.T) {
+	tests := map[string]map[string]string{
+		"missing membership file": {"-team-repositories.csv": "Web,web-app,push\n"},
+		"invalid privacy":         {"-teams.csv": "Web,web,,public,\n", "-team-membership.csv": "", "-team-repositories.csv": ""},
+		"missing fields":          {"-team-membership.csv": "Web,bob\n", "-team-repositories.csv": ""},
+		"no teams":                {"-team-membership.csv": "", "-team-repositories.csv": ""},
+	}
+
+	for name, files := range tests {
+		t.Run(name, func(t *testing.T) {
+			prefix := filepath.Join(t.TempDir(), "acme")
+			for suffix, content := range files {
+				if err := os.WriteFile(prefix+suffix, []byte(content), 0644); err != nil {
+					t.Fatal(err)
+				}
+			}
+			if _, err := ReadTeamCSVs(prefix); err == nil {
+				t.Errorf("ReadTeamCSVs() expected an error")
+			}
+		})
+	}
+}
@@ -0,0 +1,31 @@
+package team
+
+import (
+	"sort"
+
+	"github.com/mona-actions/gh-migrate-teams/internal/mapping"
+)
+
+// ExportReclaimCSV lists each team member once in the layout of gh gei reclaim-mannequin, with a
+// header. The target user is left empty to be filled in, as is the mannequin ID, which is only
+// needed when a login matches more than one mannequin.
+func (t Teams) ExportReclaimCSV() [][]string {
+	logins := make(map[string]bool)
+	for _, team := range t {
+		for _, member := range team.Members {
+			logins[member.Login] = true
+		}
+	}
+
+	sorted := make([]string, 0, len(logins))
+	for login := range logins {
+		sorted = append(sorted, login)
+	}
+	sort.Strings(sorted)
+
+	rows := [][]string{mapping.GEIColumns}
+	for _, login := range sorted {
+		rows = append(rows, []string{login, "", ""})
+	}
+	return rows
+}
@@ -0,0 +1,22 @@
+package team
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestExportReclaimCSV(t *testing.T) {
+	teams := Teams{
+		{Members: []Member{{Login: "octocat"}, {Login: "hubot"}}},
+		{Members: []Member{{Login: "octocat"}}},
+	}
+
+	want := [][]string{
+		{"mannequin-user", "mannequin-id", "target-user"},
+		{"hubot", "", ""},
+		{"octocat", "", ""},
+	}
+	if got := teams.ExportReclaimCSV(); !reflect.DeepEqual(got, want) {
+		t.Errorf("ExportReclaimCSV() = %v, want %v", got, want)
+	}
+}
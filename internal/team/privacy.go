@@ -0,0 +1,96 @@
+package team
+
+import (
+	"fmt"
+	"strings"
+)
+
+const (
+	PrivacySecret = "secret"
+	PrivacyClosed = "closed"
+
+	// Policies for secret teams that are part of a hierarchy, which GitHub does not allow
+	SecretPolicyClosed     = "closed"
+	SecretPolicyDropParent = "drop-parent"
+	SecretPolicyFail       = "fail"
+)
+
+// PrivacyAdjustment describes a change made to a team so that GitHub accepts it
+type PrivacyAdjustment struct {
+	Team   string
+	Change string
+	Reason string
+}
+
+// TranslatePrivacy converts a privacy value from the GraphQL API (SECRET, VISIBLE) or the
+// REST API (secret, closed) to the value accepted when creating a team with the REST API
+func TranslatePrivacy(privacy string) string {
+	if strings.EqualFold(privacy, PrivacySecret) {
+		return PrivacySecret
+	}
+	return PrivacyClosed
+}
+
+// ApplySecretPolicy translates the privacy of every team and resolves secret teams that have a
+// parent or child teams, which GitHub rejects. The closed policy makes them closed, drop-parent
+// keeps them secret and removes them from the hierarchy and fail returns an error instead.
+func (t Teams) ApplySecretPolicy(policy string) (Teams, []PrivacyAdjustment, error) {
+	switch policy {
+	case SecretPolicyClosed, SecretPolicyDropParent, SecretPolicyFail:
+	default:
+		return nil, nil, fmt.Errorf("unknown secret team policy %q, expected one of %s, %s, %s", policy, SecretPolicyClosed, SecretPolicyDropParent, SecretPolicyFail)
+	}
+
+	teams := make(Teams, len(t))
+	copy(teams, t)
+	children := make(map[string][]int)
+	for i := range teams {
+		teams[i].Privacy = TranslatePrivacy(teams[i].Privacy)
+		if teams[i].ParentTeamName != "" {
+			parent := strings.ToLower(teams[i].ParentTeamName)
+			children[parent] = append(children[parent], i)
+		}
+	}
+
+	adjustments := make([]PrivacyAdjustment, 0)
+	violations := make([]string, 0)
+	for i := range teams {
+		team := &teams[i]
+		if team.Privacy != PrivacySecret {
+			continue
+		}
+		teamChildren := children[strings.ToLower(team.Slug)]
+		if team.ParentTeamName == "" && len(teamChildren) == 0 {
+			continue
+		}
+
+		reason := "secret team has parent team " + team.ParentTeamName
+		if team.ParentTeamName == "" {
+			reason = "secret team has child teams"
+		}
+
+		switch policy {
+		case SecretPolicyFail:
+			violations = append(violations, team.Slug+" ("+reason+")")
+		case SecretPolicyClosed:
+			team.Privacy = PrivacyClosed
+			adjustments = append(adjustments, PrivacyAdjustment{Team: team.Slug, Change: "privacy changed from secret to closed", Reason: reason})
+		case SecretPolicyDropParent:
+			if team.ParentTeamName != "" {
+				adjustments = append(adjustments, PrivacyAdjustment{Team: team.Slug, Change: "parent team " + team.ParentTeamName + " removed", Reason: "secret team cannot have a parent team"})
+				team.ParentTeamName = ""
+				team.ParentTeamId = ""
+			}
+			for _, child := range teamChildren {
+				adjustments = append(adjustments, PrivacyAdjustment{Team: teams[child].Slug, Change: "parent team " + team.Slug + " removed", Reason: "parent team " + team.Slug + " is secret"})
+				teams[child].ParentTeamName = ""
+				teams[child].ParentTeamId = ""
+			}
+		}
+	}
+
+	if len(violations) > 0 {
+		return nil, nil, fmt.Errorf("secret teams cannot be part of a team hierarchy: %s", strings.Join(violations, ", "))
+	}
+	return teams, adjustments, nil
+}
@@ -0,0 +1,80 @@
+package team
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestTranslatePrivacy(t *testing.T) {
+	tests := map[string]string{"SECRET": "secret", "secret": "secret", "VISIBLE": "closed", "closed": "closed", "": "closed"}
+	for privacy, want := range tests {
+		if got := TranslatePrivacy(privacy); got != want {
+			t.Errorf("TranslatePrivacy(%q) = %q, want %q", privacy, got, want)
+		}
+	}
+}
+
+func TestApplySecretPolicy(t *testing.T) {
+	teams := Teams{
+		{Slug: "eng", Privacy: "VISIBLE"},
+		{Slug: "security", Privacy: "SECRET", ParentTeamName: "eng"},
+		{Slug: "incident", Privacy: "SECRET"},
+		{Slug: "incident-leads", Privacy: "VISIBLE", ParentTeamName: "incident"},
+		{Slug: "hidden", Privacy: "SECRET"},
+	}
+
+	type result struct {
+		Slug, Privacy, Parent string
+	}
+	summarize := func(teams Teams) []result {
+		results := make([]result, 0, len(teams))
+		for _, team := range teams {
+			results = append(results, result{team.Slug, team.Privacy, team.ParentTeamName})
+		}
+		return results
+	}
+
+	t.Run("closed", func(t *testing.T) {
+		got, adjustments, err := teams.ApplySecretPolicy(SecretPolicyClosed)
+		if err != nil {
+			t.Fatalf("ApplySecretPolicy() error = %v", err)
+		}
+		want := []result{{"eng", "closed", ""}, {"security", "closed", "eng"}, {"incident", "closed", ""}, {"incident-leads", "closed", "incident"}, {"hidden", "secret", ""}}
+		if !reflect.DeepEqual(summarize(got), want) {
+			t.Errorf("ApplySecretPolicy() = %v, want %v", summarize(got), want)
+		}
+		if len(adjustments) != 2 {
+			t.Errorf("ApplySecretPolicy() adjustments = %v, want 2", adjustments)
+		}
+		// The source teams are left untouched
+		if teams[1].Privacy != "SECRET" {
+			t.Errorf("ApplySecretPolicy() modified the source teams")
+		}
+	})
+
+	t.Run("drop-parent", func(t *testing.T) {
+		got, adjustments, err := teams.ApplySecretPolicy(SecretPolicyDropParent)
+		if err != nil {
+			t.Fatalf("ApplySecretPolicy() error = %v", err)
+		}
+		want := []result{{"eng", "closed", ""}, {"security", "secret", ""}, {"incident", "secret", ""}, {"incident-leads", "closed", ""}, {"hidden", "secret", ""}}
+		if !reflect.DeepEqual(summarize(got), want) {
+			t.Errorf("ApplySecretPolicy() = %v, want %v", summarize(got), want)
+		}
+		if len(adjustments) != 2 {
+			t.Errorf("ApplySecretPolicy() adjustments = %v, want 2", adjustments)
+		}
+	})
+
+	t.Run("fail", func(t *testing.T) {
+		if _, _, err := teams.ApplySecretPolicy(SecretPolicyFail); err == nil {
+			t.Errorf("ApplySecretPolicy() expected an error")
+		}
+	})
+
+	t.Run("unknown policy", func(t *testing.T) {
+		if _, _, err := teams.ApplySecretPolicy("public"); err == nil {
+			t.Errorf("ApplySecretPolicy() expected an error")
+		}
+	})
+}
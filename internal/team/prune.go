@@ -0,0 +1,117 @@
+package team
+
+import (
+	"bufio"
+	"os"
+	"path"
+	"sort"
+	"strings"
+
+	"github.com/mona-actions/gh-migrate-teams/internal/api"
+)
+
+// PruneAllowlist holds patterns for target objects that prune must never remove.
+// Entries take the form team:<slug>, member:<slug>/<login> or repository:<slug>/<repo>
+// and may use shell globs, for example member:*/deploy-bot.
+type PruneAllowlist []string
+
+func LoadPruneAllowlist(filename string) (PruneAllowlist, error) {
+	file, err := os.Open(filename)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	var allowlist PruneAllowlist
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		// Skip blank lines and comments
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		allowlist = append(allowlist, strings.ToLower(line))
+	}
+
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+
+	return allowlist, nil
+}
+
+// Allows reports whether the change removes an object protected by the allowlist
+func (a PruneAllowlist) Allows(c Change) bool {
+	key := ""
+	switch c.Action {
+	case ActionDeleteTeam:
+		key = "team:" + c.Team
+	case ActionRemoveMember:
+		key = "member:" + c.Team + "/" + c.Member
+	case ActionRemoveRepository:
+		key = "repository:" + c.Team + "/" + c.Repository
+	default:
+		return false
+	}
+	key = strings.ToLower(key)
+
+	for _, pattern := range a {
+		if matched, _ := path.Match(pattern, key); matched {
+			return true
+		}
+	}
+	return false
+}
+
+// IsRemoval reports whether the change removes access or teams from the target
+func (c Change) IsRemoval() bool {
+	return c.Action == ActionRemoveMember || c.Action == ActionRemoveRepository || c.Action == ActionDeleteTeam
+}
+
+// GetPruneTeamChanges returns a delete change for every target team that is not
+// in the given source teams. Teams that are ancestors of a source team are kept,
+// as deleting a parent team also deletes its child teams.
+func GetPruneTeamChanges(teams Teams) ([]Change, error) {
+	data, err := api.GetTargetOrganizationTeams()
+	if err != nil {
+		return nil, err
+	}
+
+	sourceSlugs := make(map[string]bool)
+	for _, team := range teams {
+		sourceSlugs[strings.ToLower(team.Slug)] = true
+	}
+
+	parents := make(map[string]string)
+	for _, team := range data {
+		parents[strings.ToLower(team["Slug"])] = strings.ToLower(team["ParentTeamName"])
+	}
+
+	// Find every target team that has a source team below it
+	ancestors := make(map[string]bool)
+	for slug := range sourceSlugs {
+		for parent, seen := parents[slug], 0; parent != "" && seen < len(parents); parent, seen = parents[parent], seen+1 {
+			ancestors[parent] = true
+		}
+	}
+
+	changes := make([]Change, 0)
+	depths := make(map[string]int)
+	for _, team := range data {
+		slug := strings.ToLower(team["Slug"])
+		if sourceSlugs[slug] || ancestors[slug] {
+			continue
+		}
+		changes = append(changes, Change{Action: ActionDeleteTeam, Team: team["Slug"], Name: team["Name"]})
+		for parent, seen := parents[slug], 0; parent != "" && seen < len(parents); parent, seen = parents[parent], seen+1 {
+			depths[slug]++
+		}
+	}
+
+	// Delete child teams before their parents so each deletion is explicit
+	sort.SliceStable(changes, func(i, j int) bool {
+		return depths[strings.ToLower(changes[i].Team)] > depths[strings.ToLower(changes[j].Team)]
+	})
+
+	return changes, nil
+}
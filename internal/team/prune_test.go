@@ -0,0 +1,31 @@
+package team
+
+import (
+	"testing"
+)
+
+func TestPruneAllowlistAllows(t *testing.T) {
+	allowlist := PruneAllowlist{"team:keep-me", "member:*/deploy-bot", "repository:team-a/legacy-*"}
+
+	tests := []struct {
+		name     string
+		change   Change
+		expected bool
+	}{
+		{"Allowlisted team", Change{Action: ActionDeleteTeam, Team: "Keep-Me"}, true},
+		{"Other team", Change{Action: ActionDeleteTeam, Team: "old-team"}, false},
+		{"Allowlisted member in any team", Change{Action: ActionRemoveMember, Team: "team-b", Member: "deploy-bot"}, true},
+		{"Other member", Change{Action: ActionRemoveMember, Team: "team-b", Member: "octocat"}, false},
+		{"Allowlisted repository", Change{Action: ActionRemoveRepository, Team: "team-a", Repository: "legacy-app"}, true},
+		{"Repository in other team", Change{Action: ActionRemoveRepository, Team: "team-b", Repository: "legacy-app"}, false},
+		{"Additions are never allowlisted", Change{Action: ActionAddMember, Team: "team-b", Member: "deploy-bot"}, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if result := allowlist.Allows(tt.change); result != tt.expected {
+				t.Errorf("Allows() = %v, expected %v", result, tt.expected)
+			}
+		})
+	}
+}
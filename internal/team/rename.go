@@ -0,0 +1,149 @@
+package team
+
+import (
+	"encoding/csv"
+	"fmt"
+	"os"
+	"regexp"
+	"strings"
+)
+
+const (
+	RenameTeamName    = "team-name"
+	RenameTeamSlug    = "team-slug"
+	RenameDescription = "description"
+	RenameRepository  = "repository"
+)
+
+// RenameRule rewrites every match of Pattern in a field using Replacement, which may
+// refer to capture groups as $1 or ${name}
+type RenameRule struct {
+	Field       string
+	Pattern     *regexp.Regexp
+	Replacement string
+}
+
+// RenameRules are applied in the order they appear in the rules file, so a rule sees
+// the result of the rules before it
+type RenameRules []RenameRule
+
+// LoadRenameRules reads a CSV file with the header field,pattern,replacement
+func LoadRenameRules(filename string) (RenameRules, error) {
+	file, err := os.Open(filename)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	reader := csv.NewReader(file)
+	reader.Comment = '#'
+	records, err := reader.ReadAll()
+	if err != nil {
+		return nil, err
+	}
+
+	rules := make(RenameRules, 0, len(records))
+	for i, record := range records {
+		// Skip header
+		if i == 0 && strings.EqualFold(record[0], "field") {
+			continue
+		}
+		if len(record) != 3 {
+			return nil, fmt.Errorf("rename rule on line %d must have a field, pattern and replacement", i+1)
+		}
+
+		field := strings.ToLower(strings.TrimSpace(record[0]))
+		switch field {
+		case RenameTeamName, RenameTeamSlug, RenameDescription, RenameRepository:
+		default:
+			return nil, fmt.Errorf("rename rule on line %d has unknown field %q, expected one of %s, %s, %s, %s", i+1, record[0], RenameTeamName, RenameTeamSlug, RenameDescription, RenameRepository)
+		}
+
+		pattern, err := regexp.Compile(record[1])
+		if err != nil {
+			return nil, fmt.Errorf("rename rule on line %d has an invalid pattern: %w", i+1, err)
+		}
+
+		rules = append(rules, RenameRule{Field: field, Pattern: pattern, Replacement: record[2]})
+	}
+
+	return rules, nil
+}
+
+// Apply returns the value after every rule for the field has been applied
+func (r RenameRules) Apply(field string, value string) string {
+	for _, rule := range r {
+		if rule.Field == field {
+			value = rule.Pattern.ReplaceAllString(value, rule.Replacement)
+		}
+	}
+	return value
+}
+
+// Rename returns the team with the rules applied to its name, slug, description and
+// repositories. When no slug rule changes the slug of a renamed team, the slug is derived
+// from the new name as GitHub does. Slug rules also apply to the parent team, which is
+// referenced by slug.
+func (t Team) Rename(rules RenameRules) Team {
+	name := t.Name
+	t.Name = rules.Apply(RenameTeamName, t.Name)
+	if slug := rules.Apply(RenameTeamSlug, t.Slug); slug != t.Slug || t.Name == name {
+		t.Slug = slug
+	} else {
+		t.Slug = Slugify(t.Name)
+	}
+	t.Description = rules.Apply(RenameDescription, t.Description)
+	if t.ParentTeamName != "" {
+		t.ParentTeamName = rules.Apply(RenameTeamSlug, t.ParentTeamName)
+	}
+
+	repositories := make([]Repository, 0, len(t.Repositories))
+	for _, repository := range t.Repositories {
+		repository.Name = rules.Apply(RenameRepository, repository.Name)
+		repositories = append(repositories, repository)
+	}
+	t.Repositories = repositories
+
+	return t
+}
+
+// Rename returns the teams with the rules applied. Children of renamed teams refer to their
+// parent by its new slug.
+func (t Teams) Rename(rules RenameRules) Teams {
+	renamed := make(Teams, 0, len(t))
+	slugs := make(map[string]string)
+	for _, team := range t {
+		renamedTeam := team.Rename(rules)
+		slugs[team.Slug] = renamedTeam.Slug
+		renamed = append(renamed, renamedTeam)
+	}
+	for i, team := range t {
+		// The parent may not be one of the teams, as when syncing by repository
+		if slug, exists := slugs[team.ParentTeamName]; exists {
+			renamed[i].ParentTeamName = slug
+		}
+	}
+	return renamed
+}
+
+// ExportRenames lists every value the rules change as field, team, source and target
+func (t Teams) ExportRenames(rules RenameRules) [][]string {
+	renames := [][]string{{"field", "team", "source", "target"}}
+	for _, team := range t {
+		renamed := team.Rename(rules)
+		add := func(field string, source string, target string) {
+			if source != target {
+				renames = append(renames, []string{field, team.Slug, source, target})
+			}
+		}
+
+		add(RenameTeamName, team.Name, renamed.Name)
+		add(RenameTeamSlug, team.Slug, renamed.Slug)
+		add(RenameDescription, team.Description, renamed.Description)
+		for i, repository := range team.Repositories {
+			add(RenameRepository, repository.Name, renamed.Repositories[i].Name)
+		}
+	}
+
+	return renames
+}
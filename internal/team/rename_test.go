@@ -0,0 +1,101 @@
+package team
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func writeRenameRules(t *testing.T, content string) string {
+	t.Helper()
+	filename := filepath.Join(t.TempDir(), "rename-rules.csv")
+	if err := os.WriteFile(filename, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+	return filename
+}
+
+func TestRename(t *testing.T) {
+	rules, err := LoadRenameRules(writeRenameRules(t, `field,pattern,replacement
+team-name,_,-
+team-name,^,acme-
+team-slug,_,-
+team-slug,^,acme-
+description,$, (migrated from old-org)
+repository,^legacy-(.*)$,$1
+`))
+	if err != nil {
+		t.Fatalf("LoadRenameRules() error = %v", err)
+	}
+
+	team := Team{
+		Name:           "platform_ops",
+		Slug:           "platform_ops",
+		Description:    "Platform operations",
+		ParentTeamName: "eng_all",
+		Repositories: []Repository{
+			{Name: "legacy-api", Permission: "push"},
+			{Name: "web", Permission: "pull"},
+		},
+	}
+
+	renamed := team.Rename(rules)
+	if renamed.Name != "acme-platform-ops" || renamed.Slug != "acme-platform-ops" {
+		t.Errorf("Rename() name = %q, slug = %q, want acme-platform-ops", renamed.Name, renamed.Slug)
+	}
+	if renamed.ParentTeamName != "acme-eng-all" {
+		t.Errorf("Rename() parent = %q, want acme-eng-all", renamed.ParentTeamName)
+	}
+	if renamed.Description != "Platform operations (migrated from old-org)" {
+		t.Errorf("Rename() description = %q", renamed.Description)
+	}
+	if renamed.Repositories[0].Name != "api" || renamed.Repositories[1].Name != "web" {
+		t.Errorf("Rename() repositories = %v, want api and web", renamed.Repositories)
+	}
+	// The source team is left untouched
+	if team.Repositories[0].Name != "legacy-api" {
+		t.Errorf("Rename() modified the source team repositories")
+	}
+
+	renames := Teams{team}.ExportRenames(rules)
+	if len(renames) != 5 {
+		t.Errorf("ExportRenames() = %v, want header and 4 renames", renames)
+	}
+}
+
+func TestRenameDerivesSlugFromName(t *testing.T) {
+	rules, err := LoadRenameRules(writeRenameRules(t, "field,pattern,replacement\nteam-name,^,Acme \n"))
+	if err != nil {
+		t.Fatalf("LoadRenameRules() error = %v", err)
+	}
+
+	renamed := Teams{
+		{Name: "Eng All", Slug: "eng-all"},
+		{Name: "Platform", Slug: "platform", ParentTeamName: "eng-all"},
+		{Name: "Web", Slug: "web", ParentTeamName: "not-synced"},
+	}.Rename(rules)
+	if renamed[0].Name != "Acme Eng All" || renamed[0].Slug != "acme-eng-all" {
+		t.Errorf("Rename() name = %q, slug = %q, want acme-eng-all", renamed[0].Name, renamed[0].Slug)
+	}
+	if renamed[1].ParentTeamName != "acme-eng-all" {
+		t.Errorf("Rename() parent = %q, want acme-eng-all", renamed[1].ParentTeamName)
+	}
+	if renamed[2].ParentTeamName != "not-synced" {
+		t.Errorf("Rename() parent = %q, want not-synced", renamed[2].ParentTeamName)
+	}
+}
+
+func TestLoadRenameRulesErrors(t *testing.T) {
+	tests := map[string]string{
+		"unknown field":   "field,pattern,replacement\nowner,^a,b\n",
+		"invalid pattern": "field,pattern,replacement\nteam-name,(,b\n",
+	}
+
+	for name, content := range tests {
+		t.Run(name, func(t *testing.T) {
+			if _, err := LoadRenameRules(writeRenameRules(t, content)); err == nil {
+				t.Errorf("LoadRenameRules() expected an error")
+			}
+		})
+	}
+}
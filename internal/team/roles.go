@@ -0,0 +1,115 @@
+package team
+
+import (
+	"fmt"
+	"log"
+	"sort"
+	"strings"
+
+	"github.com/google/go-github/v62/github"
+	"github.com/mona-actions/gh-migrate-teams/internal/api"
+)
+
+// builtinPermissions are the repository roles every organization has, using the values
+// accepted by the REST API
+var builtinPermissions = map[string]bool{"pull": true, "triage": true, "push": true, "maintain": true, "admin": true}
+
+// normalizePermission converts GraphQL and UI role names to the values accepted by the REST API.
+// Any other value is the name of a custom repository role and is kept as is.
+func normalizePermission(permission string) string {
+	switch strings.ToLower(permission) {
+	case "read", "pull":
+		return "pull"
+	case "write", "push":
+		return "push"
+	case "triage", "maintain", "admin":
+		return strings.ToLower(permission)
+	}
+	return permission
+}
+
+// CustomRoles returns the custom repository roles granted to the teams
+func (t Teams) CustomRoles() []string {
+	permissions := make([]string, 0)
+	for _, team := range t {
+		for _, repository := range team.Repositories {
+			permissions = append(permissions, repository.Permission)
+		}
+	}
+	return CustomRoles(permissions)
+}
+
+// CustomRoles returns the sorted, unique custom repository roles among the permissions
+func CustomRoles(permissions []string) []string {
+	seen := make(map[string]bool)
+	roles := make([]string, 0)
+	for _, permission := range permissions {
+		if permission == "" || builtinPermissions[permission] || seen[strings.ToLower(permission)] {
+			continue
+		}
+		seen[strings.ToLower(permission)] = true
+		roles = append(roles, permission)
+	}
+	sort.Strings(roles)
+	return roles
+}
+
+// EnsureCustomRoles checks that the custom repository roles exist in the target organization, as
+// granting a role that does not exist fails. When create is set, missing roles are created from
+// their definition in the source organization. The roles that are still missing are returned.
+func EnsureCustomRoles(roles []string, create bool) ([]string, error) {
+	if len(roles) == 0 {
+		return nil, nil
+	}
+
+	targetRoles, err := api.GetTargetCustomRepositoryRoles()
+	if err != nil {
+		return nil, fmt.Errorf("unable to list custom repository roles in target organization: %w", err)
+	}
+	missing := missingRoles(roles, targetRoles)
+	if len(missing) == 0 || !create {
+		return missing, nil
+	}
+
+	sourceRoles, err := api.GetSourceCustomRepositoryRoles()
+	if err != nil {
+		return missing, fmt.Errorf("unable to list custom repository roles in source organization: %w", err)
+	}
+	definitions := make(map[string]*github.CustomRepoRoles)
+	for _, role := range sourceRoles {
+		definitions[strings.ToLower(role.GetName())] = role
+	}
+
+	stillMissing := make([]string, 0)
+	for _, name := range missing {
+		definition, exists := definitions[strings.ToLower(name)]
+		if !exists {
+			log.Println("Custom repository role", name, "is not defined in the source organization")
+			stillMissing = append(stillMissing, name)
+			continue
+		}
+
+		log.Println("Creating custom repository role in target organization:", name)
+		if err := api.CreateCustomRepositoryRole(definition); err != nil {
+			log.Println("Unable to create custom repository role", name, "-", err)
+			stillMissing = append(stillMissing, name)
+		}
+	}
+
+	return stillMissing, nil
+}
+
+func missingRoles(roles []string, existing []*github.CustomRepoRoles) []string {
+	defined := make(map[string]bool)
+	for _, role := range existing {
+		defined[strings.ToLower(role.GetName())] = true
+	}
+
+	missing := make([]string, 0)
+	for _, role := range roles {
+		if !defined[strings.ToLower(role)] {
+			missing = append(missing, role)
+		}
+	}
+	return missing
+}
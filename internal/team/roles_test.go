@@ -0,0 +1,43 @@
+package team
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/google/go-github/v62/github"
+)
+
+func TestNormalizePermission(t *testing.T) {
+	tests := map[string]string{
+		"READ":             "pull",
+		"pull":             "pull",
+		"WRITE":            "push",
+		"TRIAGE":           "triage",
+		"MAINTAIN":         "maintain",
+		"ADMIN":            "admin",
+		"Security Auditor": "Security Auditor",
+	}
+
+	for permission, want := range tests {
+		if got := normalizePermission(permission); got != want {
+			t.Errorf("normalizePermission(%q) = %q, want %q", permission, got, want)
+		}
+	}
+}
+
+func TestCustomRoles(t *testing.T) {
+	teams := Teams{
+		{Repositories: []Repository{{Name: "a", Permission: "push"}, {Name: "b", Permission: "release-manager"}}},
+		{Repositories: []Repository{{Name: "c", Permission: "maintain"}, {Name: "d", Permission: "Auditor"}, {Name: "e", Permission: "release-manager"}}},
+	}
+
+	roles := teams.CustomRoles()
+	if want := []string{"Auditor", "release-manager"}; !reflect.DeepEqual(roles, want) {
+		t.Errorf("CustomRoles() = %v, want %v", roles, want)
+	}
+
+	existing := []*github.CustomRepoRoles{{Name: github.String("auditor")}}
+	if missing := missingRoles(roles, existing); !reflect.DeepEqual(missing, []string{"release-manager"}) {
+		t.Errorf("missingRoles() = %v, want [release-manager]", missing)
+	}
+}
@@ -0,0 +1,166 @@
+package team
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/mona-actions/gh-migrate-teams/internal/api"
+)
+
+const (
+	NotificationsEnabled  = "notifications_enabled"
+	NotificationsDisabled = "notifications_disabled"
+)
+
+// ReviewAssignment is the code review assignment of a team, which GitHub calls review
+// request delegation. Excluded members are read from the source team, or from the teams
+// CSV when importing.
+type ReviewAssignment struct {
+	Enabled         bool     `json:"enabled"`
+	Algorithm       string   `json:"algorithm,omitempty"`
+	MemberCount     int      `json:"member_count,omitempty"`
+	NotifyTeam      bool     `json:"notify_team,omitempty"`
+	ExcludedMembers []string `json:"excluded_members,omitempty"`
+	// Resolved is set once ExcludedMembers are the logins of the target users, so they are not
+	// mapped again
+	Resolved bool `json:"-"`
+}
+
+// TranslateNotificationSetting returns the REST API value of a GraphQL notification setting
+func TranslateNotificationSetting(setting string) string {
+	return strings.ToLower(setting)
+}
+
+// reviewAssignmentFromSettings reads the code review assignment from the settings returned
+// by the API, nil is returned when the settings were not part of the response
+func reviewAssignmentFromSettings(settings map[string]string) *ReviewAssignment {
+	if settings["ReviewRequestDelegationEnabled"] == "" {
+		return nil
+	}
+
+	memberCount, _ := strconv.Atoi(settings["ReviewRequestDelegationMemberCount"])
+	assignment := &ReviewAssignment{
+		Enabled:     settings["ReviewRequestDelegationEnabled"] == "true",
+		Algorithm:   strings.ToLower(settings["ReviewRequestDelegationAlgorithm"]),
+		MemberCount: memberCount,
+		NotifyTeam:  settings["ReviewRequestDelegationNotifyTeam"] == "true",
+	}
+	for _, login := range strings.Split(settings["ReviewRequestDelegationExcludedMembers"], ";") {
+		if login != "" {
+			assignment.ExcludedMembers = append(assignment.ExcludedMembers, login)
+		}
+	}
+	return assignment
+}
+
+// applySettings copies the notification and code review assignment settings returned by the API
+func (t *Team) applySettings(settings map[string]string) {
+	if settings == nil {
+		return
+	}
+	t.NotificationSetting = TranslateNotificationSetting(settings["NotificationSetting"])
+	t.ReviewAssignment = reviewAssignmentFromSettings(settings)
+}
+
+// reviewAssignmentMatches reports whether the target team already has the source team's
+// code review assignment. Excluded members are not compared, as they cannot be read from
+// every server.
+func (t Team) reviewAssignmentMatches(target *Team) bool {
+	source := t.ReviewAssignment
+	if source == nil {
+		return true
+	}
+
+	current := &ReviewAssignment{}
+	if target != nil && target.ReviewAssignment != nil {
+		current = target.ReviewAssignment
+	}
+	if !source.Enabled || !current.Enabled {
+		return source.Enabled == current.Enabled
+	}
+	return strings.EqualFold(source.Algorithm, current.Algorithm) &&
+		source.MemberCount == current.MemberCount &&
+		source.NotifyTeam == current.NotifyTeam
+}
+
+// settingsColumns lists the notification and code review assignment settings for the teams CSV
+func (t Team) settingsColumns() []string {
+	if t.ReviewAssignment == nil {
+		return []string{t.NotificationSetting, "", "", "", "", ""}
+	}
+
+	memberCount := ""
+	if t.ReviewAssignment.MemberCount > 0 {
+		memberCount = strconv.Itoa(t.ReviewAssignment.MemberCount)
+	}
+	return []string{
+		t.NotificationSetting,
+		strconv.FormatBool(t.ReviewAssignment.Enabled),
+		t.ReviewAssignment.Algorithm,
+		memberCount,
+		strconv.FormatBool(t.ReviewAssignment.NotifyTeam),
+		strings.Join(t.ReviewAssignment.ExcludedMembers, ";"),
+	}
+}
+
+// parseSettingsColumns reads the columns written by settingsColumns, which may be missing
+// from files exported by earlier versions
+func (t *Team) parseSettingsColumns(columns []string) error {
+	column := func(i int) string {
+		if i < len(columns) {
+			return columns[i]
+		}
+		return ""
+	}
+
+	switch setting := strings.ToLower(column(0)); setting {
+	case "", NotificationsEnabled, NotificationsDisabled:
+		t.NotificationSetting = setting
+	default:
+		return fmt.Errorf("notification setting must be %s or %s, got %q", NotificationsEnabled, NotificationsDisabled, column(0))
+	}
+
+	if column(1) == "" {
+		return nil
+	}
+	enabled, err := strconv.ParseBool(column(1))
+	if err != nil {
+		return fmt.Errorf("review assignment enabled must be true or false, got %q", column(1))
+	}
+	assignment := &ReviewAssignment{Enabled: enabled, Algorithm: strings.ToLower(column(2))}
+	if assignment.Algorithm != "" && assignment.Algorithm != "round_robin" && assignment.Algorithm != "load_balance" {
+		return fmt.Errorf("review assignment algorithm must be round_robin or load_balance, got %q", column(2))
+	}
+	if column(3) != "" {
+		if assignment.MemberCount, err = strconv.Atoi(column(3)); err != nil || assignment.MemberCount < 1 {
+			return fmt.Errorf("review assignment member count must be a positive number, got %q", column(3))
+		}
+	}
+	if column(4) != "" {
+		if assignment.NotifyTeam, err = strconv.ParseBool(column(4)); err != nil {
+			return fmt.Errorf("review assignment notify team must be true or false, got %q", column(4))
+		}
+	}
+	for _, login := range strings.Split(column(5), ";") {
+		if login = strings.TrimSpace(login); login != "" {
+			assignment.ExcludedMembers = append(assignment.ExcludedMembers, login)
+		}
+	}
+
+	t.ReviewAssignment = assignment
+	return nil
+}
+
+// reviewAssignmentState describes the code review assignment for the target state hash
+func (t Team) reviewAssignmentState() string {
+	if t.ReviewAssignment == nil || !t.ReviewAssignment.Enabled {
+		return "disabled"
+	}
+	return fmt.Sprintf("%s:%d:%t", strings.ToLower(t.ReviewAssignment.Algorithm), t.ReviewAssignment.MemberCount, t.ReviewAssignment.NotifyTeam)
+}
+
+// apply sets the code review assignment of the target team
+func (r ReviewAssignment) apply(slug string) error {
+	return api.UpdateTeamReviewAssignment(slug, r.Enabled, r.Algorithm, r.MemberCount, r.NotifyTeam, r.ExcludedMembers)
+}
@@ -0,0 +1,106 @@
+package team
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestApplySettings(t *testing.T) {
+	team := Team{}
+	team.applySettings(map[string]string{
+		"NotificationSetting":                    "NOTIFICATIONS_DISABLED",
+		"ReviewRequestDelegationEnabled":         "true",
+		"ReviewRequestDelegationAlgorithm":       "LOAD_BALANCE",
+		"ReviewRequestDelegationMemberCount":     "2",
+		"ReviewRequestDelegationNotifyTeam":      "false",
+		"ReviewRequestDelegationExcludedMembers": "alice;bob",
+	})
+
+	if team.NotificationSetting != NotificationsDisabled {
+		t.Errorf("NotificationSetting = %q, want %q", team.NotificationSetting, NotificationsDisabled)
+	}
+	want := &ReviewAssignment{Enabled: true, Algorithm: "load_balance", MemberCount: 2, ExcludedMembers: []string{"alice", "bob"}}
+	if !reflect.DeepEqual(team.ReviewAssignment, want) {
+		t.Errorf("ReviewAssignment = %+v, want %+v", team.ReviewAssignment, want)
+	}
+}
+
+func TestReviewAssignmentChanges(t *testing.T) {
+	enabled := &ReviewAssignment{Enabled: true, Algorithm: "round_robin", MemberCount: 1, NotifyTeam: true}
+
+	tests := []struct {
+		name   string
+		source *ReviewAssignment
+		target *Team
+		want   bool
+	}{
+		{"unknown in source", nil, &Team{}, false},
+		{"disabled and missing", &ReviewAssignment{}, nil, false},
+		{"enabled and missing", enabled, nil, true},
+		{"matching", enabled, &Team{ReviewAssignment: &ReviewAssignment{Enabled: true, Algorithm: "ROUND_ROBIN", MemberCount: 1, NotifyTeam: true}}, false},
+		{"different count", enabled, &Team{ReviewAssignment: &ReviewAssignment{Enabled: true, Algorithm: "round_robin", MemberCount: 3, NotifyTeam: true}}, true},
+		{"disabled in source", &ReviewAssignment{}, &Team{ReviewAssignment: enabled}, true},
+		{"disabled in both", &ReviewAssignment{}, &Team{ReviewAssignment: &ReviewAssignment{Algorithm: "load_balance"}}, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			source := Team{Slug: "team-a", Name: "Team A", ReviewAssignment: tt.source}
+			got := false
+			for _, change := range source.Changes(tt.target, "") {
+				if change.Action == ActionSetReviewAssignment {
+					got = true
+					if change.ReviewAssignment != tt.source {
+						t.Errorf("change.ReviewAssignment = %+v, want %+v", change.ReviewAssignment, tt.source)
+					}
+				}
+			}
+			if got != tt.want {
+				t.Errorf("set-review-assignment change = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestNotificationSettingChanges(t *testing.T) {
+	target := &Team{Slug: "team-a", Privacy: "closed", NotificationSetting: NotificationsEnabled}
+
+	if changes := (Team{Slug: "team-a", Privacy: "closed"}).Changes(target, ""); len(changes) != 0 {
+		t.Errorf("Changes() without a source setting = %v, want none", changes)
+	}
+
+	changes := Team{Slug: "team-a", Privacy: "closed", NotificationSetting: NotificationsDisabled}.Changes(target, "")
+	if len(changes) != 1 || changes[0].Action != ActionUpdateTeam || changes[0].NotificationSetting != NotificationsDisabled {
+		t.Errorf("Changes() = %v, want an update-team change to %s", changes, NotificationsDisabled)
+	}
+}
+
+func TestSettingsColumns(t *testing.T) {
+	team := Team{
+		NotificationSetting: NotificationsEnabled,
+		ReviewAssignment:    &ReviewAssignment{Enabled: true, Algorithm: "round_robin", MemberCount: 2, NotifyTeam: true, ExcludedMembers: []string{"alice", "bob"}},
+	}
+
+	columns := team.settingsColumns()
+	want := []string{"notifications_enabled", "true", "round_robin", "2", "true", "alice;bob"}
+	if !reflect.DeepEqual(columns, want) {
+		t.Fatalf("settingsColumns() = %v, want %v", columns, want)
+	}
+
+	parsed := Team{}
+	if err := parsed.parseSettingsColumns(columns); err != nil {
+		t.Fatalf("parseSettingsColumns() error = %v", err)
+	}
+	if parsed.NotificationSetting != team.NotificationSetting || !reflect.DeepEqual(parsed.ReviewAssignment, team.ReviewAssignment) {
+		t.Errorf("parseSettingsColumns() = %+v, want %+v", parsed, team)
+	}
+
+	if err := (&Team{}).parseSettingsColumns(nil); err != nil {
+		t.Errorf("parseSettingsColumns(nil) error = %v", err)
+	}
+	for _, columns := range [][]string{{"everyone"}, {"", "yes"}, {"", "true", "random"}, {"", "true", "", "0"}} {
+		if err := (&Team{}).parseSettingsColumns(columns); err == nil {
+			t.Errorf("parseSettingsColumns(%q) expected an error", columns)
+		}
+	}
+}
@@ -1,14 +1,18 @@
 package team
 
 import (
-	"encoding/csv"
+	"crypto/sha256"
+	"encoding/hex"
 	"log"
-	"os"
+	"sort"
 	"strconv"
 	"strings"
 	"time"
 
 	"github.com/mona-actions/gh-migrate-teams/internal/api"
+	"github.com/mona-actions/gh-migrate-teams/internal/checkpoint"
+	"github.com/mona-actions/gh-migrate-teams/internal/mapping"
+	"github.com/mona-actions/gh-migrate-teams/internal/report"
 	"github.com/spf13/viper"
 )
 
@@ -25,12 +29,24 @@ type Team struct {
 	Members        []Member
 	Repositories   []Repository
 	ParentTeamName string
+
+	NotificationSetting string
+	ReviewAssignment    *ReviewAssignment
+	ExternalGroup       *ExternalGroup
+	IdPGroups           []IdPGroup
+
+	// UnresolvedMembers are the source members left out of Members as their target user is not
+	// known. Any target member could be one of them, so none are pruned.
+	UnresolvedMembers []string
 }
 
 type Member struct {
 	Login string
 	Email string
 	Role  string
+
+	// Resolved is set once Login is the login of the target user, so it is not mapped again
+	Resolved bool
 }
 
 type Repository struct {
@@ -38,31 +54,37 @@ type Repository struct {
 	Permission string
 }
 
-func GetSourceOrganizationTeams() Teams {
+// GetSourceOrganizationTeams returns the teams of the source organization selected by
+// the filter, a nil filter selects every team. Teams are selected by name before their
+// members and repositories are fetched to save on API requests.
+func GetSourceOrganizationTeams(filter *Filter) Teams {
 	data := api.GetSourceOrganizationTeams()
 
-	teams := make([]Team, 0)
+	teams := make(Teams, 0)
 	for _, team := range data {
-		// Fixing privacy values
-		privacy := "SECRET"
-		if team["Privacy"] != "SECRET" {
-			privacy = "closed"
-		}
-
-		teams = append(teams, Team{
+		t := Team{
 			Id:             team["Id"],
 			Name:           team["Name"],
 			Slug:           team["Slug"],
 			Description:    team["Description"],
-			Privacy:        privacy,
+			Privacy:        TranslatePrivacy(team["Privacy"]),
 			ParentTeamId:   team["ParentTeamId"],
 			ParentTeamName: team["ParentTeamName"],
-			Members:        getTeamMemberships(team["Slug"]),
-			Repositories:   getTeamRepositories(team["Slug"]),
-		})
+		}
+		t.applySettings(team)
+		teams = append(teams, t)
 	}
 
-	return teams
+	teams = filter.SelectByName(teams)
+	for i := range teams {
+		teams[i].Members = getTeamMemberships(teams[i].Slug)
+		teams[i].Repositories = getTeamRepositories(teams[i].Slug)
+		if viper.GetBool("TEAM_SYNC_GROUPS") {
+			teams[i].IdPGroups = getTeamIdPGroups(teams[i].Slug)
+		}
+	}
+
+	return filter.SelectByContents(teams).MapNames(viper.GetString("SOURCE_ORGANIZATION"))
 }
 
 func getTeamMemberships(team string) []Member {
@@ -80,38 +102,44 @@ func getTeamMemberships(team string) []Member {
 	return members
 }
 
-func getTeamRepositories(team string) []Repository {
+// TargetRepository returns how a team of the target organization refers to a repository, which a
+// repository mapping may give as owner/name: by name when the target organization owns it and as
+// owner/name when another owner does
+func TargetRepository(repository string) string {
+	if owner, name, found := strings.Cut(repository, "/"); found && strings.EqualFold(owner, viper.GetString("TARGET_ORGANIZATION")) {
+		return name
+	}
+	return repository
+}
 
-	//check if the repo-mappings.csv file exists
-	filePath := viper.GetString("REPO_MAPPING_FILE")
-	var repoMappings map[string]string
-	if _, err := os.Stat(filePath); err == nil {
-		// Read repo mappings
-		repoMappings, err = readMappings(filePath)
-		if err != nil {
-			log.Println("Unable to read repo mappings - ", err)
+// CrossOwnerRepositories returns the repositories of the team that another owner than the target
+// organization owns, which the team cannot be granted access to
+func (t Team) CrossOwnerRepositories() []Repository {
+	repositories := make([]Repository, 0)
+	for _, repository := range t.Repositories {
+		if strings.Contains(TargetRepository(repository.Name), "/") {
+			repositories = append(repositories, repository)
 		}
 	}
+	return repositories
+}
 
+func getTeamRepositories(team string) []Repository {
+	repoMappings := mapping.Get(mapping.Repositories)
 	data := api.GetTeamRepositories(team)
 
 	repositories := make([]Repository, 0)
 	for _, repository := range data {
 		if repository["Name"] != "" {
-			// Fixing permission values
-			permission := "pull"
-			if repository["Permission"] == "WRITE" {
-				permission = "push"
-			} else if repository["Permission"] == "ADMIN" {
-				permission = "admin"
-			}
+			// Keep maintain, triage and custom repository roles
+			permission := normalizePermission(repository["Permission"])
 
 			repoName := repository["Name"]
 			sourceOrg := viper.GetString("SOURCE_ORGANIZATION")
 			repoWithOwner := sourceOrg + "/" + repoName
 			// Check if the repository name exists in the mappings
-			if newName, exists := repoMappings[repoWithOwner]; exists {
-				repoName = newName
+			if newName, exists := repoMappings.Target(repoWithOwner); exists {
+				repoName = TargetRepository(newName)
 			}
 
 			repositories = append(repositories, Repository{
@@ -124,29 +152,78 @@ func getTeamRepositories(team string) []Repository {
 	return repositories
 }
 
-func (t Team) CreateTeam() {
-	// We Send ParentTeamName as that is easiest to get the ParentTeamId
-	err := api.CreateTeam(t.Name, t.Description, t.Privacy, t.ParentTeamName)
+// CreateTeam creates the team in the target organization with its repositories and members.
+// It reports whether every write succeeded, so that a resumed run retries a team that is not
+// complete.
+func (t Team) CreateTeam() bool {
+	complete := true
+
+	// A resumed run does not try to create teams it already created
+	var err error
+	if !checkpoint.Done("created:" + t.Slug) {
+		// We Send ParentTeamName as that is easiest to get the ParentTeamId
+		err = api.CreateTeam(t.Name, t.Description, t.Privacy, t.ParentTeamName, t.NotificationSetting)
+		if outcome := report.Record(t.Slug, ActionCreateTeam, t.Name, err); outcome != report.Success && outcome != report.AlreadyExists {
+			complete = false
+		}
 
-	skipTeams := viper.GetBool("SKIP_TEAMS")
+		// Adding a wait to account for race condition
+		time.Sleep(3 * time.Second)
 
-	// Adding a wait to account for race condition
-	time.Sleep(3 * time.Second)
+		if err == nil {
+			markDone("created:" + t.Slug)
+		}
+	}
+
+	skipTeams := viper.GetBool("SKIP_TEAMS")
 
 	//skip adding repositories and members if team already exists to save on API calls
 	if err != nil && skipTeams {
 		if strings.Contains(err.Error(), "Name must be unique for this org") {
-			return
+			return complete
 		}
 	} else {
 		for _, repository := range t.Repositories {
-			api.AddTeamRepository(t.Slug, repository.Name, repository.Permission)
+			key := "repository:" + t.Slug + "/" + repository.Name
+			if checkpoint.Done(key) {
+				continue
+			}
+			err := api.AddTeamRepository(t.Slug, repository.Name, repository.Permission)
+			if outcome := report.Record(t.Slug, ActionAddRepository, repository.Name, err); outcome != report.Success {
+				log.Println("Unable to add repository", repository.Name, "to team", t.Slug, "-", err)
+				// Retrying a grant on a repository of another owner would fail again
+				complete = complete && outcome == report.CrossOwner
+				continue
+			}
+			markDone(key)
+		}
+
+		// Teams connected to an external group get their members from the group
+		if t.ExternalGroup != nil && !checkpoint.Done("external-group:"+t.Slug) {
+			err := api.LinkTeamExternalGroup(t.Slug, t.ExternalGroup.Id)
+			if report.Record(t.Slug, ActionLinkExternalGroup, t.ExternalGroup.Name, err) != report.Success {
+				log.Println("Unable to connect team", t.Slug, "to external group", t.ExternalGroup.Name, "-", err)
+				complete = false
+			} else {
+				markDone("external-group:" + t.Slug)
+			}
+		}
+
+		// Teams connected to IdP groups through team synchronization get their members from the groups
+		if len(t.IdPGroups) > 0 && !checkpoint.Done("idp-groups:"+t.Slug) {
+			err := connectIdPGroups(t.Slug, t.IdPGroups)
+			if report.Record(t.Slug, ActionConnectIdPGroups, idpGroupNames(t.IdPGroups), err) != report.Success {
+				log.Println("Unable to connect team", t.Slug, "to IdP groups", idpGroupNames(t.IdPGroups), "-", err)
+				complete = false
+			} else {
+				markDone("idp-groups:" + t.Slug)
+			}
 		}
 
 		// Check to see if user sync has been disabled
 		userSync := viper.GetString("USER_SYNC")
 
-		if userSync != "disable" {
+		if userSync != "disable" && !t.managedByIdP() {
 			authenticatedUser, err := api.GetAuthenticatedUser()
 			if err != nil {
 				log.Println("Unable to get authenticated user - ", err)
@@ -157,12 +234,23 @@ func (t Team) CreateTeam() {
 			memberMap := make(map[string]bool)
 			for _, member := range t.Members {
 				memberMap[member.Login] = true
-				api.AddTeamMember(t.Slug, member.Login, member.Role)
+				key := "member:" + t.Slug + "/" + member.Login
+				if checkpoint.Done(key) {
+					continue
+				}
+				err := api.AddTeamMember(t.Slug, member.Login, member.Role)
+				if report.Record(t.Slug, ActionAddMember, member.Login, err) != report.Success {
+					log.Println("Unable to add member", member.Login, "to team", t.Slug, "-", err)
+					complete = false
+					continue
+				}
+				markDone(key)
 			}
 
 			//If authenticated user is not part of the members, remove them from the team
 			if authUserLogin != "" && !memberMap[authUserLogin] {
 				err := api.RemoveTeamMember(t.Slug, authenticatedUser.GetLogin())
+				report.Record(t.Slug, ActionRemoveMember, authUserLogin, err)
 				if err != nil {
 					log.Println("Unable to remove authenticated user from team - ", err)
 				} else {
@@ -170,6 +258,25 @@ func (t Team) CreateTeam() {
 				}
 			}
 		}
+
+		// New teams start without code review assignment, it is set once the excluded members are part of the team
+		if t.ReviewAssignment != nil && t.ReviewAssignment.Enabled && !checkpoint.Done("review-assignment:"+t.Slug) {
+			err := t.ReviewAssignment.apply(t.Slug)
+			if report.Record(t.Slug, ActionSetReviewAssignment, t.Name, err) != report.Success {
+				log.Println("Unable to set code review assignment of team", t.Slug, "-", err)
+				complete = false
+			} else {
+				markDone("review-assignment:" + t.Slug)
+			}
+		}
+	}
+	return complete
+}
+
+// markDone records finished work in the checkpoint so a resumed run can skip it
+func markDone(key string) {
+	if err := checkpoint.MarkDone(key); err != nil {
+		log.Println("Unable to update state file - ", err)
 	}
 }
 
@@ -177,7 +284,7 @@ func (t Teams) ExportTeamMemberships() [][]string {
 	memberships := make([][]string, 0)
 	for _, team := range t {
 		for _, member := range team.Members {
-			memberships = append(memberships, []string{team.Name, member.Login, member.Email})
+			memberships = append(memberships, []string{team.Name, member.Login, member.Email, strings.ToLower(member.Role)})
 		}
 	}
 
@@ -195,6 +302,8 @@ func (t Teams) ExportTeamRepositories() [][]string {
 	return repositories
 }
 
+// GetRepositoryTeams returns the teams with access to a source repository, given as owner/name,
+// with their source names so that they can be selected before the team mapping is applied
 func GetRepositoryTeams(repository string) Teams {
 	//split the repository string to get the owner and repo name
 	repo := strings.Split(repository, "/")
@@ -206,70 +315,148 @@ func GetRepositoryTeams(repository string) Teams {
 	if err != nil {
 		log.Println("Unable to get repository teams - ", err)
 	}
-	parentTeamID := ""
-	parentTeamName := ""
-
-	// Check if the team-mappings.csv file exists
-	filePath := viper.GetString("TEAM_MAPPING_FILE")
-	var teamMappings map[string]string
-	if _, err := os.Stat(filePath); err == nil {
-		// Read team mappings
-		teamMappings, err = readMappings(filePath)
-		if err != nil {
-			log.Println("Unable to read team mappings - ", err)
-		}
-	}
 
 	teams := make(Teams, 0, len(data))
 	for _, team := range data {
+		parentTeamID := ""
+		parentTeamName := ""
 		if team.Parent != nil {
 			parentTeamID = strconv.FormatInt(team.Parent.GetID(), 10)
-			parentTeamName = *team.Parent.Name
+			parentTeamName = team.Parent.GetSlug()
 		}
 
-		teamName := team.GetName()
-		teamSlug := team.GetSlug()
-		// Check if the team name exists in the mappings
-		if newName, exists := teamMappings[owner+"/"+teamName]; exists {
-			teamName = newName
-			teamSlug = newName
+		sourceSlug := team.GetSlug()
+		settings, err := api.GetSourceTeamSettings(sourceSlug)
+		if err != nil {
+			log.Println("Unable to get settings of team", sourceSlug, "-", err)
 		}
 
 		team := Team{
 			Id:             strconv.FormatInt(team.GetID(), 10),
-			Name:           teamName,
-			Slug:           teamSlug,
+			Name:           team.GetName(),
+			Slug:           team.GetSlug(),
 			Description:    team.GetDescription(),
-			Privacy:        team.GetPrivacy(),
+			Privacy:        TranslatePrivacy(team.GetPrivacy()),
 			ParentTeamId:   parentTeamID,
 			ParentTeamName: parentTeamName,
 			Members:        getTeamMemberships(*team.Slug),
 			Repositories:   getTeamRepositories(*team.Slug),
 		}
+		team.applySettings(settings)
+		if viper.GetBool("TEAM_SYNC_GROUPS") {
+			team.IdPGroups = getTeamIdPGroups(sourceSlug)
+		}
 		teams = append(teams, team)
 	}
 
 	return teams
 }
 
-func readMappings(filePath string) (map[string]string, error) {
-	mappings := make(map[string]string)
+// MapNames renames the teams of owner listed in the team mapping file, which is keyed by
+// owner/name or owner/slug. The slug is derived from the new name, and children of renamed
+// teams refer to their parent by its new slug.
+func (t Teams) MapNames(owner string) Teams {
+	teamMappings := mapping.Get(mapping.Teams)
+	if teamMappings.Len() == 0 {
+		return t
+	}
+	target := func(name string, slug string) (string, bool) {
+		if newName, exists := teamMappings.Target(owner + "/" + name); exists {
+			return newName, true
+		}
+		return teamMappings.Target(owner + "/" + slug)
+	}
 
-	file, err := os.Open(filePath)
+	slugs := make(map[string]string)
+	for i := range t {
+		if newName, exists := target(t[i].Name, t[i].Slug); exists {
+			slugs[t[i].Slug] = Slugify(newName)
+			t[i].Name = newName
+			t[i].Slug = Slugify(newName)
+		}
+	}
+	for i := range t {
+		if t[i].ParentTeamName == "" {
+			continue
+		}
+		// The parent may not be one of the teams, as when syncing by repository
+		if slug, exists := slugs[t[i].ParentTeamName]; exists {
+			t[i].ParentTeamName = slug
+		} else if newName, exists := teamMappings.Target(owner + "/" + t[i].ParentTeamName); exists {
+			t[i].ParentTeamName = Slugify(newName)
+		}
+	}
+	return t
+}
+
+// GetTargetTeam returns the current state of a team in the target organization
+// or nil if the team does not exist there
+func GetTargetTeam(slug string) (*Team, error) {
+	data, err := api.GetTargetTeam(slug)
+	if err != nil || data == nil {
+		return nil, err
+	}
+
+	members, err := api.GetTargetTeamMembers(slug)
 	if err != nil {
 		return nil, err
 	}
-	defer file.Close()
 
-	reader := csv.NewReader(file)
-	records, err := reader.ReadAll()
+	repositories, err := api.GetTargetTeamRepositories(slug)
 	if err != nil {
 		return nil, err
 	}
 
-	for _, record := range records[1:] { // Skip header
-		mappings[record[0]] = record[1]
+	settings, err := api.GetTargetTeamSettings(slug)
+	if err != nil {
+		return nil, err
+	}
+
+	team := &Team{
+		Id:             data["Id"],
+		Name:           data["Name"],
+		Slug:           data["Slug"],
+		Description:    data["Description"],
+		Privacy:        data["Privacy"],
+		ParentTeamName: data["ParentTeamName"],
+		Members:        make([]Member, 0, len(members)),
+		Repositories:   make([]Repository, 0, len(repositories)),
+	}
+	team.applySettings(settings)
+	for _, member := range members {
+		team.Members = append(team.Members, Member{Login: member["Login"], Role: member["Role"]})
+	}
+	for _, repository := range repositories {
+		team.Repositories = append(team.Repositories, Repository{Name: repository["Name"], Permission: repository["Permission"]})
+	}
+
+	return team, nil
+}
+
+// StateHash returns a hash of the parts of a target team that a sync writes to,
+// an empty string is returned for a team that does not exist
+func (t *Team) StateHash() string {
+	if t == nil {
+		return ""
+	}
+
+	lines := []string{
+		"name:" + t.Name,
+		"description:" + t.Description,
+		"privacy:" + t.Privacy,
+		"parent:" + t.ParentTeamName,
+		"notifications:" + t.NotificationSetting,
+		"review-assignment:" + t.reviewAssignmentState(),
+	}
+	for _, member := range t.Members {
+		lines = append(lines, "member:"+strings.ToLower(member.Login)+":"+strings.ToLower(member.Role))
+	}
+	for _, repository := range t.Repositories {
+		lines = append(lines, "repository:"+strings.ToLower(repository.Name)+":"+repository.Permission)
 	}
+	// Sort so the hash does not depend on the order the API returned items in
+	sort.Strings(lines[6:])
 
-	return mappings, nil
+	sum := sha256.Sum256([]byte(strings.Join(lines, "\n")))
+	return hex.EncodeToString(sum[:])
 }
@@ -0,0 +1,81 @@
+package team
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/mona-actions/gh-migrate-teams/internal/mapping"
+	"github.com/spf13/viper"
+)
+
+func TestMapNames(t *testing.T) {
+	filename := filepath.Join(t.TempDir(), "teams.csv")
+	if err := os.WriteFile(filename, []byte("source,target\nacme/Platform Team,Platform Engineering\nacme/web,Frontend\nacme/infra,Infrastructure\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		viper.Reset()
+		mapping.LoadConfigured()
+	})
+	viper.Set("TEAM_MAPPING_FILE", filename)
+	if err := mapping.LoadConfigured(); err != nil {
+		t.Fatal(err)
+	}
+
+	teams := Teams{
+		{Name: "Platform Team", Slug: "platform-team"},
+		{Name: "Web", Slug: "web", ParentTeamName: "platform-team"},
+		{Name: "Ops", Slug: "ops", ParentTeamName: "infra"},
+	}.MapNames("acme")
+
+	want := []struct{ name, slug, parent string }{
+		{"Platform Engineering", "platform-engineering", ""},
+		{"Frontend", "frontend", "platform-engineering"},
+		// A parent that is not one of the teams is mapped by its slug
+		{"Ops", "ops", "infrastructure"},
+	}
+	for i, w := range want {
+		if teams[i].Name != w.name || teams[i].Slug != w.slug || teams[i].ParentTeamName != w.parent {
+			t.Errorf("team %d = %s (%s) with parent %q, want %s (%s) with parent %q", i, teams[i].Name, teams[i].Slug, teams[i].ParentTeamName, w.name, w.slug, w.parent)
+		}
+	}
+
+	if other := (Teams{{Name: "Web", Slug: "web"}}).MapNames("other"); other[0].Name != "Web" {
+		t.Errorf("MapNames() renamed a team of another owner to %s", other[0].Name)
+	}
+}
+
+func TestTargetRepository(t *testing.T) {
+	t.Cleanup(viper.Reset)
+	viper.Set("TARGET_ORGANIZATION", "acme")
+
+	tests := map[string]string{
+		"web":           "web",
+		"ACME/web":      "web",
+		"acme-labs/web": "acme-labs/web",
+	}
+	for repository, want := range tests {
+		if got := TargetRepository(repository); got != want {
+			t.Errorf("TargetRepository(%q) = %q, want %q", repository, got, want)
+		}
+	}
+}
+
+func TestCrossOwnerRepositories(t *testing.T) {
+	t.Cleanup(viper.Reset)
+	viper.Set("TARGET_ORGANIZATION", "acme")
+
+	source := Team{Slug: "web", Repositories: []Repository{{Name: "web", Permission: "push"}, {Name: "acme-labs/web", Permission: "pull"}}}
+	want := []Repository{{Name: "acme-labs/web", Permission: "pull"}}
+	if got := source.CrossOwnerRepositories(); !reflect.DeepEqual(got, want) {
+		t.Errorf("CrossOwnerRepositories() = %v, want %v", got, want)
+	}
+
+	// Grants on repositories of another owner are never planned, as the target team does not list them
+	target := &Team{Slug: "web", Repositories: []Repository{{Name: "web", Permission: "push"}}}
+	if changes := source.Changes(target, ""); len(changes) != 0 {
+		t.Errorf("Changes() = %v, want none", changes)
+	}
+}
@@ -2,8 +2,10 @@ package export
 
 import (
 	"encoding/csv"
+	"log"
 	"os"
 
+	"github.com/mona-actions/gh-migrate-teams/internal/mapping"
 	"github.com/mona-actions/gh-migrate-teams/internal/repository"
 	"github.com/mona-actions/gh-migrate-teams/internal/team"
 	"github.com/pterm/pterm"
@@ -11,11 +13,25 @@ import (
 )
 
 func CreateCSVs() {
+	filter, err := team.LoadFilter()
+	if err != nil {
+		log.Fatalf("Unable to select teams - %v", err)
+	}
+	// Repository mappings rename the repositories of the exported teams
+	if err := mapping.LoadConfigured(); err != nil {
+		log.Fatalf("Unable to read mapping file - %v", err)
+	}
+
 	// Get all teams from source organization
 	teamsSpinnerSuccess, _ := pterm.DefaultSpinner.Start("Fetching teams from organization...")
-	teams := team.GetSourceOrganizationTeams()
+	teams := team.GetSourceOrganizationTeams(filter)
 	teamsSpinnerSuccess.Success()
 
+	// Create team csv
+	createCSVTeamsSpinnerSuccess, _ := pterm.DefaultSpinner.Start("Creating team csv...")
+	createCSV(teams.ExportTeams(), viper.GetString("OUTPUT_FILE")+"-teams.csv")
+	createCSVTeamsSpinnerSuccess.Success()
+
 	// Create team membership csv
 	createCSVMembershipsSpinnerSuccess, _ := pterm.DefaultSpinner.Start("Creating team membership csv...")
 	createCSV(teams.ExportTeamMemberships(), viper.GetString("OUTPUT_FILE")+"-team-membership.csv")
@@ -26,6 +42,32 @@ func CreateCSVs() {
 	createCSV(teams.ExportTeamRepositories(), viper.GetString("OUTPUT_FILE")+"-team-repositories.csv")
 	createCSVRepositoriesSpinnerSuccess.Success()
 
+	// Create team IdP group csv
+	if viper.GetBool("TEAM_SYNC_GROUPS") {
+		createCSVGroupsSpinnerSuccess, _ := pterm.DefaultSpinner.Start("Creating team IdP group csv...")
+		createCSV(teams.ExportIdPGroups(), viper.GetString("OUTPUT_FILE")+"-team-idp-groups.csv")
+		createCSVGroupsSpinnerSuccess.Success()
+	}
+
+	// Create mannequin reclaim csv
+	if viper.GetBool("RECLAIM_CSV") {
+		createCSVReclaimSpinnerSuccess, _ := pterm.DefaultSpinner.Start("Creating mannequin reclaim csv...")
+		createCSV(teams.ExportReclaimCSV(), viper.GetString("OUTPUT_FILE")+"-reclaim.csv")
+		createCSVReclaimSpinnerSuccess.Success()
+	}
+
+	// Preview the effect of rename rules on the exported teams
+	if filename := viper.GetString("RENAME_RULES_FILE"); filename != "" {
+		renamesSpinnerSuccess, _ := pterm.DefaultSpinner.Start("Creating rename preview csv...")
+		rules, err := team.LoadRenameRules(filename)
+		if err != nil {
+			renamesSpinnerSuccess.Fail()
+			log.Fatalf("Unable to read rename rules - %v", err)
+		}
+		createCSV(teams.ExportRenames(rules), viper.GetString("OUTPUT_FILE")+"-rename-preview.csv")
+		renamesSpinnerSuccess.Success()
+	}
+
 	// Get all repositories from source organization
 	repositoriesSpinnerSuccess, _ := pterm.DefaultSpinner.Start("Fetching repositories from organization...")
 	repositories := repository.GetSourceOrganizationRepositories()
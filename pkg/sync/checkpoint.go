@@ -0,0 +1,94 @@
+package sync
+
+import (
+	"log"
+	"sync/atomic"
+
+	"github.com/mona-actions/gh-migrate-teams/internal/checkpoint"
+	"github.com/mona-actions/gh-migrate-teams/internal/team"
+	"github.com/spf13/viper"
+)
+
+// unfinished is set when a team or repository of the run is left for a resumed run to retry
+var unfinished atomic.Bool
+
+// openCheckpoint starts recording progress to the state file, or continues the
+// progress of an interrupted run when resuming
+func openCheckpoint(run string) {
+	unfinished.Store(false)
+	filename := viper.GetString("STATE_FILE")
+	if filename == "" {
+		return
+	}
+
+	if err := checkpoint.Open(filename, run, viper.GetBool("RESUME")); err != nil {
+		log.Fatalf("Unable to open state file - %v", err)
+	}
+	if viper.GetBool("RESUME") {
+		log.Println("Resuming from state file " + filename)
+	}
+}
+
+// runDescription identifies a run in the state file. The team selection is part of it, so that
+// a run is only resumed with the teams it selected.
+func runDescription(run string, filter *team.Filter) string {
+	if filter.IsEmpty() {
+		return run
+	}
+	return run + " (" + filter.String() + ")"
+}
+
+// loadCheckpoint reads source data fetched by an interrupted run into v
+func loadCheckpoint(key string, v interface{}) bool {
+	loaded, err := checkpoint.Load(key, v)
+	if err != nil {
+		log.Fatalf("Unable to read %s from state file - %v", key, err)
+	}
+	return loaded
+}
+
+func saveCheckpoint(key string, v interface{}) {
+	if err := checkpoint.Save(key, v); err != nil {
+		log.Println("Unable to update state file - ", err)
+	}
+}
+
+func markTeamDone(t team.Team) {
+	markCheckpointDone("team:" + t.Slug)
+}
+
+func markCheckpointDone(key string) {
+	if err := checkpoint.MarkDone(key); err != nil {
+		log.Println("Unable to update state file - ", err)
+	}
+}
+
+// pendingTeams returns the teams that were not finished by an interrupted run
+func pendingTeams(teams []team.Team) []team.Team {
+	pending := make([]team.Team, 0, len(teams))
+	for _, t := range teams {
+		if checkpoint.Done("team:" + t.Slug) {
+			log.Println("Skipping team finished in a previous run: " + t.Slug)
+			continue
+		}
+		pending = append(pending, t)
+	}
+	return pending
+}
+
+// markUnfinished keeps the state file when the run finishes, so that a resumed run retries the
+// teams and repositories with failed writes
+func markUnfinished() {
+	unfinished.Store(true)
+}
+
+// closeCheckpoint removes the state file once the run has finished, unless some teams or
+// repositories are left for a resumed run to retry
+func closeCheckpoint() {
+	if unfinished.Load() && viper.GetString("STATE_FILE") != "" {
+		log.Println("Keeping state file " + viper.GetString("STATE_FILE") + ", run again with --resume to retry the writes that failed")
+	}
+	if err := checkpoint.Close(!unfinished.Load()); err != nil {
+		log.Println("Unable to remove state file - ", err)
+	}
+}
@@ -0,0 +1,231 @@
+package sync
+
+import (
+	"fmt"
+	"log"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"github.com/mona-actions/gh-migrate-teams/internal/api"
+	"github.com/mona-actions/gh-migrate-teams/internal/checkpoint"
+	"github.com/mona-actions/gh-migrate-teams/internal/identity"
+	"github.com/mona-actions/gh-migrate-teams/internal/mapping"
+	"github.com/mona-actions/gh-migrate-teams/internal/report"
+	"github.com/mona-actions/gh-migrate-teams/internal/repository"
+	"github.com/mona-actions/gh-migrate-teams/internal/team"
+	"github.com/pterm/pterm"
+	"github.com/spf13/viper"
+)
+
+const (
+	operationAddCollaborator        = "add-collaborator"
+	operationAddOutsideCollaborator = "add-outside-collaborator"
+)
+
+// SyncCollaborators gives the direct and outside collaborators of every source repository
+// the same access to the repository of the same name in the target organization
+func SyncCollaborators() {
+	sourceOrganization := viper.GetString("SOURCE_ORGANIZATION")
+	openCheckpoint("sync collaborators " + sourceOrganization + " to " + viper.GetString("TARGET_ORGANIZATION"))
+	runId := startRun()
+	loadMappings()
+
+	// Get all repositories from source organization, unless an interrupted run already fetched them
+	var names []string
+	if !loadCheckpoint("repositories", &names) {
+		repositoriesSpinnerSuccess, _ := pterm.DefaultSpinner.Start("Fetching repositories from organization...")
+		names = repository.GetSourceOrganizationRepositoryNames()
+		saveCheckpoint("repositories", names)
+		repositoriesSpinnerSuccess.Success()
+	}
+
+	repos := make([]string, 0, len(names))
+	for _, name := range names {
+		repos = append(repos, sourceOrganization+"/"+name)
+	}
+
+	syncCollaborators(repos)
+	closeCheckpoint()
+	finishRun(runId)
+}
+
+// syncCollaborators syncs the collaborators of the repositories, given as owner/name
+func syncCollaborators(repos []string) {
+	collaboratorsSpinnerSuccess, _ := pterm.DefaultSpinner.WithDelay(1 * time.Minute).Start("Syncing repository collaborators to target organization...")
+	rules := loadRenameRules()
+
+	pending := make([]string, 0, len(repos))
+	for _, repo := range repos {
+		if !checkpoint.Done("collaborators:" + repo) {
+			pending = append(pending, repo)
+		}
+	}
+
+	// Read the collaborators of every repository first, so that their logins are resolved together
+	sources := make([][]repository.Collaborator, len(pending))
+	fetched := make([]bool, len(pending))
+	runConcurrently(concurrency(), len(pending), func(i int) {
+		collaborators, err := sourceCollaborators(pending[i])
+		if err != nil {
+			log.Println("Unable to get collaborators of repository", pending[i], "-", err)
+			return
+		}
+		sources[i], fetched[i] = collaborators, true
+	})
+	logins := collaboratorLogins(sources)
+
+	var failed atomic.Int64
+	runConcurrently(concurrency(), len(pending), func(i int) {
+		if !fetched[i] || !syncRepositoryCollaborators(pending[i], mapCollaborators(sources[i], logins), rules) {
+			failed.Add(1)
+			markUnfinished()
+			return
+		}
+		markCheckpointDone("collaborators:" + pending[i])
+	})
+
+	if failed.Load() > 0 {
+		collaboratorsSpinnerSuccess.Warning("Unable to sync the collaborators of " + strconv.FormatInt(failed.Load(), 10) + " of " + strconv.Itoa(len(repos)) + " repositories")
+		return
+	}
+	collaboratorsSpinnerSuccess.Success("Synced the collaborators of " + strconv.Itoa(len(repos)) + " repositories")
+}
+
+// sourceCollaborators returns the direct and outside collaborators of a source repository,
+// given as owner/name
+func sourceCollaborators(repo string) ([]repository.Collaborator, error) {
+	owner, name, found := strings.Cut(repo, "/")
+	if !found {
+		return nil, fmt.Errorf("invalid repository %s, expected owner/name", repo)
+	}
+	return repository.GetDirectCollaborators(owner, name)
+}
+
+// collaboratorLogins resolves the target logins of the collaborators the same way as those of
+// team members: the mapping file first, then IDENTITY_MATCH, then EMU_SHORTCODE. It returns
+// the target logins keyed by the lowercase source login and leaves collaborators that
+// IDENTITY_MATCH did not resolve out.
+func collaboratorLogins(sources [][]repository.Collaborator) map[string]string {
+	mapped := mappedLogins()
+	identities := make([]identity.Identity, 0)
+	seen := make(map[string]bool)
+	for _, collaborators := range sources {
+		for _, collaborator := range collaborators {
+			key := strings.ToLower(collaborator.Login)
+			if !mapped[key] && !seen[key] {
+				seen[key] = true
+				identities = append(identities, identity.Identity{Login: collaborator.Login})
+			}
+		}
+	}
+
+	resolved, unresolved := resolveLogins(identities, true)
+	if unresolved > 0 {
+		log.Println(strconv.Itoa(unresolved) + " collaborators were not resolved and are not added to their repositories, review them in " + viper.GetString("IDENTITY_REVIEW_FILE"))
+	}
+
+	logins := make(map[string]string)
+	for _, collaborators := range sources {
+		for _, collaborator := range collaborators {
+			key := strings.ToLower(collaborator.Login)
+			if target, exists := resolved[key]; exists {
+				logins[key] = target
+			} else if resolved == nil || mapped[key] {
+				logins[key] = targetLogin(collaborator.Login)
+			}
+		}
+	}
+	return logins
+}
+
+// mapCollaborators replaces the logins of the collaborators with their target logins and leaves
+// out those without one
+func mapCollaborators(collaborators []repository.Collaborator, logins map[string]string) []repository.Collaborator {
+	mapped := make([]repository.Collaborator, 0, len(collaborators))
+	for _, collaborator := range collaborators {
+		if target, exists := logins[strings.ToLower(collaborator.Login)]; exists {
+			collaborator.Login = target
+			mapped = append(mapped, collaborator)
+		}
+	}
+	return mapped
+}
+
+// targetCollaboratorRepository returns the target repository of a source repository, given as
+// owner/name, with the repository mapping file and the rename rules applied as for team access.
+// A repository mapped to another owner is returned as owner/name.
+func targetCollaboratorRepository(repo string, rules team.RenameRules) string {
+	_, name, _ := strings.Cut(repo, "/")
+	if target, exists := mapping.Get(mapping.Repositories).Target(repo); exists {
+		name = team.TargetRepository(target)
+	}
+	return rules.Apply(team.RenameRepository, name)
+}
+
+// collaboratorOperation returns the report operation of adding a collaborator, which tells
+// outside collaborators apart
+func collaboratorOperation(collaborator repository.Collaborator) string {
+	if collaborator.Outside {
+		return operationAddOutsideCollaborator
+	}
+	return operationAddCollaborator
+}
+
+// syncRepositoryCollaborators adds the missing collaborators of a source repository, given as
+// owner/name with the collaborators already mapped to their target logins, to the target
+// repository and records invitations that have not been accepted. It returns false when the
+// collaborators could not be compared.
+func syncRepositoryCollaborators(repo string, source []repository.Collaborator, rules team.RenameRules) bool {
+	if len(source) == 0 {
+		return true
+	}
+
+	// Collaborators are never written to repositories of other organizations
+	targetName := targetCollaboratorRepository(repo, rules)
+	if strings.Contains(targetName, "/") {
+		err := fmt.Errorf("%w: %s is not owned by %s, add its collaborators with --target-organization set to its owner", report.ErrCrossOwner, targetName, viper.GetString("TARGET_ORGANIZATION"))
+		report.RecordRepository(targetName, operationAddCollaborator, "", err)
+		log.Println("Skipping collaborators of repository", repo, "-", err)
+		return true
+	}
+
+	target, err := repository.GetTargetCollaborators(targetName)
+	if err != nil {
+		log.Println("Unable to get collaborators of target repository", targetName, "-", err)
+		report.RecordRepository(targetName, operationAddCollaborator, "", err)
+		return false
+	}
+	invitations, err := repository.GetTargetInvitations(targetName)
+	if err != nil {
+		log.Println("Unable to get invitations of target repository", targetName, "-", err)
+		return false
+	}
+
+	missing, waiting := repository.CollaboratorChanges(source, target, invitations)
+	for _, invitation := range waiting {
+		report.RecordInvitation(report.Invitation{Repository: targetName, Login: invitation.Login, Permission: invitation.Permission, CreatedAt: invitation.CreatedAt})
+	}
+
+	for _, collaborator := range missing {
+		operation := collaboratorOperation(collaborator)
+		if collaborator.Previous != "" {
+			err := api.ChangeRepositoryCollaborator(targetName, collaborator.Login, collaborator.Permission, collaborator.Previous)
+			if report.RecordRepository(targetName, operation, collaborator.Login, err) != report.Success {
+				log.Println("Unable to change permission of collaborator", collaborator.Login, "on repository", targetName, "-", err)
+			}
+			continue
+		}
+		invited, err := api.AddRepositoryCollaborator(targetName, collaborator.Login, collaborator.Permission)
+		if report.RecordRepository(targetName, operation, collaborator.Login, err) != report.Success {
+			log.Println("Unable to add collaborator", collaborator.Login, "to repository", targetName, "-", err)
+			continue
+		}
+		if invited {
+			report.RecordInvitation(report.Invitation{Repository: targetName, Login: collaborator.Login, Permission: collaborator.Permission, CreatedAt: time.Now().UTC().Format(time.RFC3339)})
+		}
+	}
+
+	return true
+}
@@ -0,0 +1,35 @@
+package sync
+
+import (
+	"fmt"
+	"log"
+
+	"github.com/mona-actions/gh-migrate-teams/internal/report"
+	"github.com/mona-actions/gh-migrate-teams/internal/team"
+	"github.com/spf13/viper"
+)
+
+// linkExternalGroups connects teams to the external groups set by EXTERNAL_GROUP_MAPPING_FILE,
+// recording the teams that have no matching group in the run report
+func linkExternalGroups(teams []team.Team) []team.Team {
+	filename := viper.GetString("EXTERNAL_GROUP_MAPPING_FILE")
+	if filename == "" {
+		return teams
+	}
+
+	mappings, err := team.LoadExternalGroupMappings(filename)
+	if err != nil {
+		log.Fatalf("Unable to read external group mapping file - %v", err)
+	}
+	groups, err := team.GetTargetExternalGroups()
+	if err != nil {
+		log.Fatalf("Unable to list external groups of target organization - %v", err)
+	}
+
+	linked, unlinked := team.Teams(teams).LinkExternalGroups(mappings, groups)
+	for _, u := range unlinked {
+		log.Println("Team", u.Team, "is not connected to an external group and keeps its direct members -", u.Reason)
+		report.Record(u.Team, team.ActionLinkExternalGroup, u.Group, fmt.Errorf("%w: %s", report.ErrNotFound, u.Reason))
+	}
+	return linked
+}
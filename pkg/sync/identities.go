@@ -0,0 +1,180 @@
+package sync
+
+import (
+	"log"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/mona-actions/gh-migrate-teams/internal/identity"
+	"github.com/mona-actions/gh-migrate-teams/internal/mapping"
+	"github.com/mona-actions/gh-migrate-teams/internal/team"
+	"github.com/spf13/viper"
+)
+
+// resolveIdentities replaces the logins of team members with the target users found by
+// IDENTITY_MATCH. Members listed in the mapping file keep their mapping. Members without a
+// single match are moved to the unresolved members of their teams and written to
+// IDENTITY_REVIEW_FILE instead of being guessed. fetchSource reads the identities of the source organization in addition to
+// the member emails the teams already have.
+func resolveIdentities(teams []team.Team, fetchSource bool) []team.Team {
+	mapped := mappedLogins()
+	sources := make(map[string]*identity.Identity)
+	order := make([]string, 0)
+	for _, t := range teams {
+		for _, member := range t.Members {
+			key := strings.ToLower(member.Login)
+			if mapped[key] {
+				continue
+			}
+			if _, exists := sources[key]; !exists {
+				sources[key] = &identity.Identity{Login: member.Login}
+				order = append(order, key)
+			}
+			if member.Email != "" {
+				sources[key].Emails = append(sources[key].Emails, member.Email)
+			}
+		}
+		if t.ReviewAssignment == nil {
+			continue
+		}
+		for _, login := range t.ReviewAssignment.ExcludedMembers {
+			key := strings.ToLower(login)
+			if _, exists := sources[key]; !exists && !mapped[key] {
+				sources[key] = &identity.Identity{Login: login}
+				order = append(order, key)
+			}
+		}
+	}
+
+	list := make([]identity.Identity, 0, len(order))
+	for _, key := range order {
+		list = append(list, *sources[key])
+	}
+	resolved, unresolved := resolveLogins(list, fetchSource)
+	if resolved == nil {
+		return teams
+	}
+
+	for i := range teams {
+		members := make([]team.Member, 0, len(teams[i].Members))
+		for _, member := range teams[i].Members {
+			key := strings.ToLower(member.Login)
+			if target, exists := resolved[key]; exists {
+				member.Login = target
+				member.Resolved = true
+			} else if !mapped[key] {
+				teams[i].UnresolvedMembers = append(teams[i].UnresolvedMembers, member.Login)
+				continue
+			}
+			members = append(members, member)
+		}
+		teams[i].Members = members
+		teams[i].ReviewAssignment = resolveExcludedMembers(teams[i].ReviewAssignment, resolved, mapped)
+	}
+
+	log.Println("Resolved " + strconv.Itoa(len(resolved)) + " of " + strconv.Itoa(len(resolved)+unresolved) + " members to target users")
+	if unresolved > 0 {
+		log.Println(strconv.Itoa(unresolved) + " members were not resolved and are not added to their teams, nor are members of their teams pruned, review them in " + viper.GetString("IDENTITY_REVIEW_FILE"))
+	}
+
+	return teams
+}
+
+// resolveExcludedMembers replaces the members excluded from code review assignment with their
+// resolved target users and their mapping, and leaves out the ones without either, which GitHub
+// would reject
+func resolveExcludedMembers(assignment *team.ReviewAssignment, resolved map[string]string, mapped map[string]bool) *team.ReviewAssignment {
+	if assignment == nil || len(assignment.ExcludedMembers) == 0 {
+		return assignment
+	}
+	result := *assignment
+	result.ExcludedMembers = make([]string, 0, len(assignment.ExcludedMembers))
+	for _, login := range assignment.ExcludedMembers {
+		key := strings.ToLower(login)
+		if target, exists := resolved[key]; exists {
+			result.ExcludedMembers = append(result.ExcludedMembers, target)
+		} else if mapped[key] {
+			result.ExcludedMembers = append(result.ExcludedMembers, targetLogin(login))
+		}
+	}
+	result.Resolved = true
+	return &result
+}
+
+// reviewed holds the identities that were not resolved during the run, so that resolving the
+// collaborators after the teams adds to IDENTITY_REVIEW_FILE instead of replacing it
+var (
+	reviewMu sync.Mutex
+	reviewed []identity.Resolution
+)
+
+// resolveLogins finds the target users of the source identities with IDENTITY_MATCH. It returns
+// the target logins keyed by the lowercase source login, nil when IDENTITY_MATCH is not set, and
+// the number of identities without a single match, which are written to IDENTITY_REVIEW_FILE.
+func resolveLogins(sources []identity.Identity, fetchSource bool) (map[string]string, int) {
+	methods, err := identity.ParseMethods(viper.GetString("IDENTITY_MATCH"))
+	if err != nil {
+		log.Fatalf("Unable to resolve identities - %v", err)
+	}
+	if len(methods) == 0 {
+		return nil, 0
+	}
+
+	if fetchSource {
+		found, err := identity.GetSourceIdentities()
+		if err != nil {
+			log.Fatalf("Unable to read identities of source organization - %v", err)
+		}
+		index := make(map[string]int)
+		for i, source := range sources {
+			index[strings.ToLower(source.Login)] = i
+		}
+		for _, f := range found {
+			if i, exists := index[strings.ToLower(f.Login)]; exists {
+				sources[i].Emails = append(sources[i].Emails, f.Emails...)
+				sources[i].NameId = f.NameId
+			}
+		}
+	}
+
+	targets, err := identity.GetTargetIdentities(viper.GetString("TARGET_ENTERPRISE"))
+	if err != nil {
+		log.Fatalf("Unable to read identities of target organization - %v", err)
+	}
+
+	resolved := make(map[string]string)
+	unresolved := make([]identity.Resolution, 0)
+	for _, r := range identity.Resolve(sources, targets, methods) {
+		if r.Status == identity.Resolved {
+			resolved[strings.ToLower(r.Source)] = r.Target
+		} else {
+			unresolved = append(unresolved, r)
+		}
+	}
+
+	if len(unresolved) > 0 {
+		reviewMu.Lock()
+		defer reviewMu.Unlock()
+		for _, r := range unresolved {
+			if !slices.ContainsFunc(reviewed, func(seen identity.Resolution) bool { return strings.EqualFold(seen.Source, r.Source) }) {
+				reviewed = append(reviewed, r)
+			}
+		}
+		if err := identity.WriteReview(viper.GetString("IDENTITY_REVIEW_FILE"), reviewed); err != nil {
+			log.Println("Unable to write identity review file - ", err)
+		}
+	}
+
+	return resolved, len(unresolved)
+}
+
+// mappedLogins returns the lowercase source logins listed in the mapping file
+func mappedLogins() map[string]bool {
+	logins := make(map[string]bool)
+	for _, login := range mapping.Get(mapping.Users).Sources() {
+		logins[strings.ToLower(login)] = true
+	}
+	return logins
+}
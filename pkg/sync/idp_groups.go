@@ -0,0 +1,43 @@
+package sync
+
+import (
+	"fmt"
+	"log"
+
+	"github.com/mona-actions/gh-migrate-teams/internal/mapping"
+	"github.com/mona-actions/gh-migrate-teams/internal/report"
+	"github.com/mona-actions/gh-migrate-teams/internal/team"
+	"github.com/spf13/viper"
+)
+
+// mapIdPGroups translates the team synchronization IdP groups of the teams with
+// IDP_GROUP_MAPPING_FILE, recording groups that are not available in the target organization
+func mapIdPGroups(teams []team.Team) []team.Team {
+	connected := false
+	for _, t := range teams {
+		connected = connected || len(t.IdPGroups) > 0
+	}
+	if !connected {
+		return teams
+	}
+
+	var mappings *mapping.Mapping
+	if filename := viper.GetString("IDP_GROUP_MAPPING_FILE"); filename != "" {
+		var err error
+		mappings, err = team.LoadIdPGroupMappings(filename)
+		if err != nil {
+			log.Fatalf("Unable to read IdP group mapping file - %v", err)
+		}
+	}
+	groups, err := team.GetTargetIdPGroups()
+	if err != nil {
+		log.Fatalf("Unable to list IdP groups of target organization - %v", err)
+	}
+
+	mapped, unmapped := team.Teams(teams).MapIdPGroups(mappings, groups)
+	for _, u := range unmapped {
+		log.Println("Team", u.Team, "is not connected to an IdP group -", u.Reason)
+		report.Record(u.Team, team.ActionConnectIdPGroups, u.Group, fmt.Errorf("%w: %s", report.ErrNotFound, u.Reason))
+	}
+	return mapped
+}
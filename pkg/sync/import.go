@@ -0,0 +1,48 @@
+package sync
+
+import (
+	"log"
+	"os"
+	"strconv"
+
+	"github.com/mona-actions/gh-migrate-teams/internal/team"
+	"github.com/pterm/pterm"
+	"github.com/spf13/viper"
+)
+
+// ImportTeams creates the teams described by the CSV files written by export in the
+// target organization, without access to the source organization
+func ImportTeams() {
+	prefix := viper.GetString("IMPORT_PREFIX")
+	loadMappings()
+
+	teamsSpinnerSuccess, _ := pterm.DefaultSpinner.Start("Reading teams from CSV files...")
+	teams, err := team.ReadTeamCSVs(prefix)
+	if err != nil {
+		teamsSpinnerSuccess.Fail()
+		log.Fatalf("Unable to read teams from CSV files - %v", err)
+	}
+	teamsSpinnerSuccess.Success("Read " + strconv.Itoa(len(teams)) + " teams from CSV files with prefix " + prefix)
+
+	openCheckpoint("import " + prefix + " to " + viper.GetString("TARGET_ORGANIZATION"))
+	runId := startRun()
+
+	teams = resolveIdentities(teams, false)
+	teams = resolveShortcodeLogins(teams)
+
+	// Map members
+	if os.Getenv("GHMT_MAPPING_FILE") != "" || viper.GetString("EMU_SHORTCODE") != "" {
+		for i := range teams {
+			teams[i] = mapMembers(teams[i])
+		}
+	}
+
+	teams = linkExternalGroups(teams)
+	teams = mapIdPGroups(teams)
+	teams = renameTeams(teams)
+
+	// The CSV files may only hold some of the teams, so never delete the others
+	syncTeams(teams, false)
+	closeCheckpoint()
+	finishRun(runId)
+}
@@ -0,0 +1,40 @@
+package sync
+
+import (
+	"log"
+
+	"github.com/mona-actions/gh-migrate-teams/internal/journal"
+	"github.com/pterm/pterm"
+	"github.com/spf13/viper"
+)
+
+// startRun opens the journal that records every write of this run and returns the run ID.
+// A resumed run keeps the ID of the interrupted run so it can be rolled back as a whole.
+func startRun() string {
+	var runId string
+	if !loadCheckpoint("run-id", &runId) {
+		runId = journal.NewRunId()
+		saveCheckpoint("run-id", runId)
+	}
+
+	if filename := viper.GetString("JOURNAL_FILE"); filename != "" {
+		if err := journal.Open(filename, runId); err != nil {
+			log.Fatalf("Unable to open journal file - %v", err)
+		}
+	}
+
+	pterm.Info.Println("Starting run " + runId)
+	return runId
+}
+
+func finishRun(runId string) {
+	writeReport(runId)
+
+	if err := journal.Close(); err != nil {
+		log.Println("Unable to close journal file - ", err)
+	}
+
+	if viper.GetString("JOURNAL_FILE") != "" {
+		pterm.Info.Println("Run " + runId + " finished. Writes were recorded in " + viper.GetString("JOURNAL_FILE") + " and can be undone with: migrate-teams rollback --run " + runId)
+	}
+}
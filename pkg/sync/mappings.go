@@ -0,0 +1,15 @@
+package sync
+
+import (
+	"log"
+
+	"github.com/mona-actions/gh-migrate-teams/internal/mapping"
+)
+
+// loadMappings reads the user, team and repository mapping files once for the run, stopping
+// before any change is made when one of them is malformed or conflicting
+func loadMappings() {
+	if err := mapping.LoadConfigured(); err != nil {
+		log.Fatalf("Unable to read mapping file - %v", err)
+	}
+}
@@ -0,0 +1,136 @@
+package sync
+
+import (
+	"log"
+	"os"
+	"strconv"
+
+	"github.com/mona-actions/gh-migrate-teams/internal/plan"
+	"github.com/mona-actions/gh-migrate-teams/internal/report"
+	"github.com/mona-actions/gh-migrate-teams/internal/team"
+	"github.com/pterm/pterm"
+	"github.com/spf13/viper"
+)
+
+func CreatePlan() {
+	loadMappings()
+
+	// Get all teams from source organization
+	teamsSpinnerSuccess, _ := pterm.DefaultSpinner.Start("Fetching teams from organization...")
+	teams := team.GetSourceOrganizationTeams(loadTeamFilter())
+	teamsSpinnerSuccess.Success()
+
+	teams = resolveIdentities(teams, true)
+	teams = resolveShortcodeLogins(teams)
+
+	// Map members
+	if os.Getenv("GHMT_MAPPING_FILE") != "" || viper.GetString("EMU_SHORTCODE") != "" {
+		for i := range teams {
+			teams[i] = mapMembers(teams[i])
+		}
+	}
+
+	teams = linkExternalGroups(teams)
+	teams = mapIdPGroups(teams)
+	teams = renameTeams(teams)
+	teams = applySecretPolicy(teams)
+
+	// Create parent teams before their children
+	teams = orderTeams(teams)
+
+	// Compare each source team with its current state in the target organization
+	planSpinnerSuccess, _ := pterm.DefaultSpinner.Start("Comparing teams with target organization...")
+	changes, targetState, err := planChanges(teams, true)
+	if err != nil {
+		planSpinnerSuccess.Fail()
+		log.Fatalf("Unable to compare teams with target organization - %v", err)
+	}
+	changes, err = filterPruneChanges(changes)
+	if err != nil {
+		planSpinnerSuccess.Fail()
+		log.Fatalf("Refusing to prune - %v", err)
+	}
+	planSpinnerSuccess.Success()
+
+	p := plan.New(viper.GetString("SOURCE_ORGANIZATION"), viper.GetString("TARGET_ORGANIZATION"))
+	p.TargetState = targetState
+	p.Changes = changes
+
+	if err := p.Save(viper.GetString("PLAN_FILE")); err != nil {
+		log.Fatalf("Unable to write plan file - %v", err)
+	}
+
+	for _, change := range p.Changes {
+		pterm.Println("  " + change.String())
+	}
+	pterm.Success.Println("Plan with " + strconv.Itoa(len(p.Changes)) + " changes written to " + viper.GetString("PLAN_FILE") + " (hash " + p.Hash + ")")
+}
+
+func ApplyPlan() {
+	p, err := plan.Load(viper.GetString("PLAN_FILE"))
+	if err != nil {
+		log.Fatalf("Unable to load plan - %v", err)
+	}
+	viper.Set("SOURCE_ORGANIZATION", p.SourceOrganization)
+	viper.Set("TARGET_ORGANIZATION", p.TargetOrganization)
+
+	// Refuse to apply if any team in the plan changed in the target since planning
+	driftSpinnerSuccess, _ := pterm.DefaultSpinner.Start("Checking target organization for drift...")
+	drifted := make([]string, 0)
+	for slug, hash := range p.TargetState {
+		target, err := team.GetTargetTeam(slug)
+		if err != nil {
+			driftSpinnerSuccess.Fail()
+			log.Fatalf("Unable to read team %s from target organization - %v", slug, err)
+		}
+		if target.StateHash() != hash {
+			drifted = append(drifted, slug)
+		}
+	}
+	if len(drifted) > 0 {
+		driftSpinnerSuccess.Fail()
+		log.Fatalf("Target organization has drifted since the plan was created, teams changed: %v. Create a new plan.", drifted)
+	}
+	driftSpinnerSuccess.Success()
+
+	permissions := make([]string, 0)
+	for _, change := range p.Changes {
+		if change.Action == team.ActionAddRepository {
+			permissions = append(permissions, change.Permission)
+		}
+	}
+	ensureCustomRoles(team.CustomRoles(permissions))
+
+	runId := startRun()
+	defer finishRun(runId)
+
+	applySpinnerSuccess, _ := pterm.DefaultSpinner.Start("Applying plan to target organization...")
+	failed := 0
+	for _, change := range p.Changes {
+		log.Println("Applying: " + change.String())
+		err := change.Apply()
+		report.Record(change.Team, change.Action, change.Subject(), err)
+		if err != nil {
+			log.Println("Unable to apply change", change.String(), "-", err)
+			failed++
+		}
+	}
+
+	// Set the parent of any team that was created before its parent existed
+	for _, change := range p.Changes {
+		if change.Action != team.ActionCreateTeam || change.ParentTeam == "" {
+			continue
+		}
+		t := team.Team{Slug: change.Team, ParentTeamName: change.ParentTeam}
+		if err := t.EnsureParent(); err != nil {
+			log.Println("Unable to set parent team", change.ParentTeam, "for team", change.Team, "-", err)
+			failed++
+		}
+	}
+
+	if failed > 0 {
+		applySpinnerSuccess.Warning(strconv.Itoa(failed) + " of " + strconv.Itoa(len(p.Changes)) + " changes failed to apply")
+		return
+	}
+	applySpinnerSuccess.Success("Applied " + strconv.Itoa(len(p.Changes)) + " changes")
+}
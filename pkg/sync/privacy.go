@@ -0,0 +1,29 @@
+package sync
+
+import (
+	"log"
+
+	"github.com/mona-actions/gh-migrate-teams/internal/report"
+	"github.com/mona-actions/gh-migrate-teams/internal/team"
+	"github.com/spf13/viper"
+)
+
+// applySecretPolicy resolves secret teams that are part of a hierarchy using SECRET_TEAM_POLICY
+// and records every adjustment in the run report
+func applySecretPolicy(teams []team.Team) []team.Team {
+	policy := viper.GetString("SECRET_TEAM_POLICY")
+	if policy == "" {
+		policy = team.SecretPolicyClosed
+	}
+
+	adjusted, adjustments, err := team.Teams(teams).ApplySecretPolicy(policy)
+	if err != nil {
+		log.Fatalf("Unable to sync teams - %v", err)
+	}
+
+	for _, adjustment := range adjustments {
+		log.Println("Adjusting team", adjustment.Team+":", adjustment.Change, "-", adjustment.Reason)
+		report.RecordAdjustment(report.Adjustment{Team: adjustment.Team, Change: adjustment.Change, Reason: adjustment.Reason})
+	}
+	return adjusted
+}
@@ -0,0 +1,264 @@
+package sync
+
+import (
+	"fmt"
+	"log"
+	"strconv"
+	gosync "sync"
+
+	"github.com/mona-actions/gh-migrate-teams/internal/api"
+	"github.com/mona-actions/gh-migrate-teams/internal/report"
+	"github.com/mona-actions/gh-migrate-teams/internal/team"
+	"github.com/pterm/pterm"
+	"github.com/spf13/viper"
+)
+
+// planChanges compares each team with its current state in the target organization and
+// returns the changes needed to match the source, along with a hash of each target team
+func planChanges(teams []team.Team, pruneTeams bool) ([]team.Change, map[string]string, error) {
+	authUserLogin := ""
+	if viper.GetString("USER_SYNC") != "disable" {
+		authenticatedUser, err := api.GetAuthenticatedUser()
+		if err != nil {
+			log.Println("Unable to get authenticated user - ", err)
+		}
+		authUserLogin = authenticatedUser.GetLogin()
+	}
+
+	// Read the target teams concurrently, keeping the results in team order
+	teamChanges := make([][]team.Change, len(teams))
+	hashes := make([]string, len(teams))
+	errs := make([]error, len(teams))
+	runConcurrently(concurrency(), len(teams), func(i int) {
+		target, err := team.GetTargetTeam(teams[i].Slug)
+		if err != nil {
+			errs[i] = fmt.Errorf("unable to read team %s from target organization: %w", teams[i].Slug, err)
+			return
+		}
+		if target != nil && teams[i].ExternalGroup != nil {
+			if err := target.LoadExternalGroup(); err != nil {
+				errs[i] = fmt.Errorf("unable to read the external group of team %s from target organization: %w", teams[i].Slug, err)
+				return
+			}
+		}
+		if target != nil && len(teams[i].IdPGroups) > 0 {
+			if err := target.LoadIdPGroups(); err != nil {
+				errs[i] = fmt.Errorf("unable to read the IdP groups of team %s from target organization: %w", teams[i].Slug, err)
+				return
+			}
+		}
+
+		hashes[i] = target.StateHash()
+		//skip teams that already exist to save on API calls
+		if target != nil && viper.GetBool("SKIP_TEAMS") {
+			return
+		}
+		teamChanges[i] = teams[i].Changes(target, authUserLogin)
+	})
+
+	changes := make([]team.Change, 0)
+	targetState := make(map[string]string)
+	for i, t := range teams {
+		if errs[i] != nil {
+			return nil, nil, errs[i]
+		}
+		targetState[t.Slug] = hashes[i]
+		changes = append(changes, teamChanges[i]...)
+	}
+
+	if pruneTeams {
+		deletions, err := planTeamDeletions(teams, targetState)
+		if err != nil {
+			return nil, nil, err
+		}
+		changes = append(changes, deletions...)
+	}
+
+	return changes, targetState, nil
+}
+
+// planTeamDeletions returns the target teams to delete when pruning teams, recording
+// the state of each one in targetState
+func planTeamDeletions(teams []team.Team, targetState map[string]string) ([]team.Change, error) {
+	if !viper.GetBool("PRUNE") || !viper.GetBool("PRUNE_TEAMS") {
+		return nil, nil
+	}
+
+	deletions, err := team.GetPruneTeamChanges(teams)
+	if err != nil {
+		return nil, fmt.Errorf("unable to list teams in target organization: %w", err)
+	}
+	for _, deletion := range deletions {
+		target, err := team.GetTargetTeam(deletion.Team)
+		if err != nil {
+			return nil, fmt.Errorf("unable to read team %s from target organization: %w", deletion.Team, err)
+		}
+		targetState[deletion.Team] = target.StateHash()
+	}
+
+	return deletions, nil
+}
+
+// filterPruneChanges drops removals protected by the prune allowlist and fails when the
+// remaining removals exceed the prune limit, so nothing is deleted on a bad run
+func filterPruneChanges(changes []team.Change) ([]team.Change, error) {
+	var allowlist team.PruneAllowlist
+	if filename := viper.GetString("PRUNE_ALLOWLIST"); filename != "" {
+		var err error
+		allowlist, err = team.LoadPruneAllowlist(filename)
+		if err != nil {
+			return nil, fmt.Errorf("unable to read prune allowlist: %w", err)
+		}
+	}
+
+	filtered := make([]team.Change, 0, len(changes))
+	removals := 0
+	for _, change := range changes {
+		if change.IsRemoval() {
+			if allowlist.Allows(change) {
+				log.Println("Keeping allowlisted object, skipping:", change.String())
+				continue
+			}
+			removals++
+		}
+		filtered = append(filtered, change)
+	}
+
+	limit := viper.GetInt("PRUNE_LIMIT")
+	if removals > limit {
+		return nil, fmt.Errorf("prune would remove %d objects which is more than the prune limit of %d", removals, limit)
+	}
+
+	return filtered, nil
+}
+
+// scopeRemovalsToRepositories drops repository removals for repositories outside of
+// the repository list, as access to those repositories was never read from the source
+func scopeRemovalsToRepositories(changes []team.Change, repoList []string) []team.Change {
+	repoMap := targetRepositoryNames(repoList)
+
+	scoped := make([]team.Change, 0, len(changes))
+	for _, change := range changes {
+		if change.Action == team.ActionRemoveRepository && !repoMap[change.Repository] {
+			continue
+		}
+		scoped = append(scoped, change)
+	}
+	return scoped
+}
+
+// reconcileTeams applies only the changes needed for the target teams to match the
+// source teams and lists what changed. When repoList is set, pruning of repository
+// access is limited to the repositories in the list.
+func reconcileTeams(teams []team.Team, pruneTeams bool, repoList []string) {
+	// Teams finished by an interrupted run are not compared again
+	pending := pendingTeams(teams)
+	changes, targetState, err := planChanges(pending, false)
+	if err != nil {
+		log.Fatalf("Unable to compare teams with target organization - %v", err)
+	}
+
+	// Deciding which teams to delete needs every source team, not only the pending ones
+	if pruneTeams {
+		deletions, err := planTeamDeletions(teams, targetState)
+		if err != nil {
+			log.Fatalf("Unable to compare teams with target organization - %v", err)
+		}
+		changes = append(changes, deletions...)
+	}
+
+	if repoList != nil {
+		changes = scopeRemovalsToRepositories(changes, repoList)
+	}
+
+	// Grants on repositories of another owner are not compared with the target, only reported
+	for _, t := range pending {
+		for _, repository := range t.CrossOwnerRepositories() {
+			err := api.CrossOwnerError(t.Slug, repository.Name)
+			log.Println("Unable to add repository", repository.Name, "to team", t.Slug, "-", err)
+			report.Record(t.Slug, team.ActionAddRepository, repository.Name, err)
+		}
+	}
+
+	changes, err = filterPruneChanges(changes)
+	if err != nil {
+		log.Fatalf("Refusing to prune - %v", err)
+	}
+
+	// Group the changes by team so independent teams can be reconciled concurrently
+	teamChanges := make(map[string][]team.Change)
+	for _, t := range pending {
+		teamChanges[t.Slug] = make([]team.Change, 0)
+	}
+	otherChanges := make([]team.Change, 0)
+	for _, change := range changes {
+		if _, exists := teamChanges[change.Team]; exists {
+			teamChanges[change.Team] = append(teamChanges[change.Team], change)
+		} else {
+			otherChanges = append(otherChanges, change)
+		}
+	}
+
+	var mu gosync.Mutex
+	teamApplied := make(map[string][]team.Change)
+	forEachTeam(pending, func(t team.Team) {
+		applied := applyChanges(teamChanges[t.Slug])
+		// Teams with failed changes are left for a resumed run to compare again
+		if len(applied) == len(teamChanges[t.Slug]) {
+			markTeamDone(t)
+		} else {
+			markUnfinished()
+		}
+		mu.Lock()
+		teamApplied[t.Slug] = applied
+		mu.Unlock()
+	})
+
+	applied := make([]team.Change, 0, len(changes))
+	for _, t := range pending {
+		applied = append(applied, teamApplied[t.Slug]...)
+	}
+	// Team deletions run last and one at a time
+	applied = append(applied, applyChanges(otherChanges)...)
+
+	printReconcileSummary(teams, applied)
+}
+
+// applyChanges applies the changes in order and returns the ones that succeeded
+func applyChanges(changes []team.Change) []team.Change {
+	applied := make([]team.Change, 0, len(changes))
+	for _, change := range changes {
+		log.Println("Applying:", change.String())
+		err := change.Apply()
+		report.Record(change.Team, change.Action, change.Subject(), err)
+		if err != nil {
+			log.Println("Unable to apply change", change.String(), "-", err)
+			continue
+		}
+		applied = append(applied, change)
+	}
+	return applied
+}
+
+// printReconcileSummary lists the changes made to each team during a reconcile run
+func printReconcileSummary(teams []team.Team, applied []team.Change) {
+	counts := make(map[string]int)
+	for _, change := range applied {
+		pterm.Println("  " + change.String())
+		counts[change.Team]++
+	}
+
+	tableData := pterm.TableData{{"Team", "Changes"}}
+	for _, t := range teams {
+		tableData = append(tableData, []string{t.Slug, strconv.Itoa(counts[t.Slug])})
+		delete(counts, t.Slug)
+	}
+	// Deleted teams are not part of the source teams
+	for _, change := range applied {
+		if change.Action == team.ActionDeleteTeam {
+			tableData = append(tableData, []string{change.Team, strconv.Itoa(counts[change.Team])})
+		}
+	}
+	pterm.DefaultTable.WithHasHeader().WithData(tableData).Render()
+	pterm.Info.Println("Reconciled " + strconv.Itoa(len(teams)) + " teams with " + strconv.Itoa(len(applied)) + " changes")
+}
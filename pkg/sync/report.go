@@ -0,0 +1,79 @@
+package sync
+
+import (
+	"log"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/mona-actions/gh-migrate-teams/internal/report"
+	"github.com/pterm/pterm"
+	"github.com/spf13/viper"
+)
+
+// writeReport saves the outcome of every operation of the run as JSON and CSV and
+// prints the outcome counts of each team
+func writeReport(runId string) {
+	r := report.New(runId, viper.GetString("SOURCE_ORGANIZATION"), viper.GetString("TARGET_ORGANIZATION"))
+
+	if filename := viper.GetString("REPORT_FILE"); filename != "" {
+		filename, base := reportFiles(filename)
+		if err := r.SaveJSON(filename); err != nil {
+			log.Println("Unable to write report - ", err)
+		}
+		if err := r.SaveCSV(base + ".csv"); err != nil {
+			log.Println("Unable to write report - ", err)
+		}
+		pterm.Info.Println("Wrote report of " + strconv.Itoa(len(r.Results)) + " operations to " + filename + " and " + base + ".csv")
+
+		if len(r.Adjustments) > 0 {
+			if err := r.SaveAdjustmentsCSV(base + "-adjustments.csv"); err != nil {
+				log.Println("Unable to write report - ", err)
+			}
+			pterm.Info.Println("Wrote " + strconv.Itoa(len(r.Adjustments)) + " team adjustments to " + base + "-adjustments.csv")
+		}
+
+		if len(r.Invitations) > 0 {
+			if err := r.SaveInvitationsCSV(base + "-invitations.csv"); err != nil {
+				log.Println("Unable to write report - ", err)
+			}
+			pterm.Info.Println("Wrote " + strconv.Itoa(len(r.Invitations)) + " pending repository invitations to " + base + "-invitations.csv")
+		}
+	}
+
+	if len(r.Adjustments) > 0 {
+		adjustments := pterm.TableData{{"Team", "Change", "Reason"}}
+		for _, adjustment := range r.Adjustments {
+			adjustments = append(adjustments, []string{adjustment.Team, adjustment.Change, adjustment.Reason})
+		}
+		pterm.Warning.Println("The following teams were adjusted so that GitHub accepts them:")
+		pterm.DefaultTable.WithHasHeader().WithData(adjustments).Render()
+	}
+
+	if len(r.Invitations) > 0 {
+		invitations := pterm.TableData{{"Repository", "Login", "Permission", "Invited at"}}
+		for _, invitation := range r.Invitations {
+			invitations = append(invitations, []string{invitation.Repository, invitation.Login, invitation.Permission, invitation.CreatedAt})
+		}
+		pterm.Warning.Println("The following repository invitations have not been accepted yet:")
+		pterm.DefaultTable.WithHasHeader().WithData(invitations).Render()
+	}
+
+	if len(r.Results) == 0 {
+		return
+	}
+	pterm.DefaultTable.WithHasHeader().WithData(r.TableData()).Render()
+	if failures := r.Failures(); failures > 0 {
+		pterm.Warning.Println(strconv.Itoa(failures) + " of " + strconv.Itoa(len(r.Results)) + " operations failed, see the report for details")
+	}
+}
+
+// reportFiles returns the name of the JSON report and the base name of its CSV copies. A
+// report file named .csv is written as JSON next to the CSV copy, rather than overwritten by it.
+func reportFiles(filename string) (string, string) {
+	base := strings.TrimSuffix(filename, filepath.Ext(filename))
+	if strings.EqualFold(filepath.Ext(filename), ".csv") {
+		return base + ".json", base
+	}
+	return filename, base
+}
@@ -0,0 +1,22 @@
+package sync
+
+import (
+	"log"
+	"strings"
+
+	"github.com/mona-actions/gh-migrate-teams/internal/team"
+	"github.com/pterm/pterm"
+	"github.com/spf13/viper"
+)
+
+// ensureCustomRoles warns about custom repository roles that are missing in the target
+// organization, creating them from the source organization when CREATE_CUSTOM_ROLES is set
+func ensureCustomRoles(roles []string) {
+	missing, err := team.EnsureCustomRoles(roles, viper.GetBool("CREATE_CUSTOM_ROLES"))
+	if err != nil {
+		log.Println("Unable to check custom repository roles - ", err)
+	}
+	if len(missing) > 0 {
+		pterm.Warning.Println("Custom repository roles missing in the target organization, grants of these roles will fail: " + strings.Join(missing, ", "))
+	}
+}
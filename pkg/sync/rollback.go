@@ -0,0 +1,121 @@
+package sync
+
+import (
+	"log"
+	"strconv"
+
+	"github.com/mona-actions/gh-migrate-teams/internal/api"
+	"github.com/mona-actions/gh-migrate-teams/internal/journal"
+	"github.com/pterm/pterm"
+	"github.com/spf13/viper"
+)
+
+// Rollback undoes the writes of a sync run in reverse order. Teams created by the run
+// are deleted, grants added to existing teams are revoked and roles or permissions the
+// run changed are restored. Writes that cannot be undone automatically, such as
+// removals and setting changes, are listed for review.
+func Rollback() {
+	runId := viper.GetString("RUN_ID")
+	filename := viper.GetString("JOURNAL_FILE")
+	dryRun := viper.GetBool("DRY_RUN")
+
+	entries, err := journal.Read(filename, runId)
+	if err != nil {
+		log.Fatalf("Unable to read journal - %v", err)
+	}
+	if len(entries) == 0 {
+		log.Fatalf("No writes recorded for run %s in %s", runId, filename)
+	}
+
+	// Access granted on a team created by the run goes away when the team is deleted
+	createdTeams := make(map[string]bool)
+	for _, entry := range entries {
+		if entry.Action == journal.TeamCreated && entry.Succeeded() {
+			createdTeams[entry.Organization+"/"+entry.Team] = true
+		}
+	}
+
+	// The rollback is journaled as a run of its own
+	if !dryRun {
+		if err := journal.Open(filename, runId+"-rollback"); err != nil {
+			log.Fatalf("Unable to open journal file - %v", err)
+		}
+		defer journal.Close()
+	}
+
+	rollbackSpinnerSuccess, _ := pterm.DefaultSpinner.Start("Rolling back run " + runId + "...")
+	undone, failed := 0, 0
+	manual := pterm.TableData{{"Timestamp", "Action", "Organization", "Team", "Object"}}
+	for i := len(entries) - 1; i >= 0; i-- {
+		entry := entries[i]
+		if !entry.Succeeded() {
+			continue
+		}
+
+		createdByRun := createdTeams[entry.Organization+"/"+entry.Team]
+		var undo func() error
+		description := ""
+		switch {
+		case entry.Action == journal.TeamCreated:
+			description = "delete team " + entry.Team
+			undo = func() error { return api.DeleteTeam(entry.Team) }
+		case createdByRun:
+			// Removed together with the team
+			continue
+		case entry.Action == journal.MemberAdded:
+			description = "remove " + entry.Member + " from " + entry.Team
+			undo = func() error { return api.RemoveTeamMember(entry.Team, entry.Member) }
+		case entry.Action == journal.MemberRoleChanged:
+			description = "restore " + entry.Member + " as " + entry.Previous + " of " + entry.Team
+			undo = func() error { return api.AddTeamMember(entry.Team, entry.Member, entry.Previous) }
+		case entry.Action == journal.RepositoryGranted:
+			description = "revoke " + entry.Team + " access to " + entry.Repository
+			undo = func() error { return api.RemoveTeamRepository(entry.Team, entry.Repository) }
+		case entry.Action == journal.RepositoryChanged:
+			description = "restore " + entry.Team + " " + entry.Previous + " access to " + entry.Repository
+			undo = func() error { return api.AddTeamRepository(entry.Team, entry.Repository, entry.Previous) }
+		case entry.Action == journal.CollaboratorAdded || entry.Action == journal.CollaboratorInvited:
+			description = "remove collaborator " + entry.Member + " from " + entry.Repository
+			undo = func() error { return api.RemoveRepositoryCollaborator(entry.Repository, entry.Member) }
+		case entry.Action == journal.CollaboratorChanged:
+			description = "restore collaborator " + entry.Member + " " + entry.Previous + " access to " + entry.Repository
+			undo = func() error {
+				return api.ChangeRepositoryCollaborator(entry.Repository, entry.Member, entry.Previous, entry.Permission)
+			}
+		case entry.Action == journal.ExternalGroupLinked:
+			description = "disconnect " + entry.Team + " from external group " + entry.Group
+			undo = func() error { return api.UnlinkTeamExternalGroup(entry.Team) }
+		default:
+			manual = append(manual, []string{entry.Timestamp.Format("2006-01-02T15:04:05Z"), entry.Action, entry.Organization, entry.Team, entry.Member + entry.Repository})
+			continue
+		}
+
+		if dryRun {
+			log.Println("Would " + description + " in " + entry.Organization)
+			undone++
+			continue
+		}
+
+		viper.Set("TARGET_ORGANIZATION", entry.Organization)
+		log.Println("Rolling back: " + description + " in " + entry.Organization)
+		if err := undo(); err != nil {
+			log.Println("Unable to "+description+" -", err)
+			failed++
+			continue
+		}
+		undone++
+	}
+
+	if dryRun {
+		rollbackSpinnerSuccess.Success(strconv.Itoa(undone) + " writes would be rolled back")
+	} else if failed > 0 {
+		rollbackSpinnerSuccess.Warning("Rolled back " + strconv.Itoa(undone) + " writes, " + strconv.Itoa(failed) + " failed")
+	} else {
+		rollbackSpinnerSuccess.Success("Rolled back " + strconv.Itoa(undone) + " writes")
+	}
+
+	if len(manual) > 1 {
+		pterm.Warning.Println("The following writes cannot be undone automatically and need to be reviewed:")
+		pterm.DefaultTable.WithHasHeader().WithData(manual).Render()
+	}
+}
@@ -0,0 +1,85 @@
+package sync
+
+import (
+	"log"
+	"strconv"
+	"strings"
+
+	"github.com/mona-actions/gh-migrate-teams/internal/api"
+	"github.com/mona-actions/gh-migrate-teams/internal/mapping"
+	"github.com/mona-actions/gh-migrate-teams/internal/team"
+	"github.com/spf13/viper"
+)
+
+// resolveShortcodeLogins checks the logins derived from EMU_SHORTCODE against the members of the
+// target organization. Members in the mapping file keep their mapping, and members resolved by
+// identity keep their target login. Members whose derived login is not a member of the target
+// organization are moved to the unresolved members of their teams and written to
+// EMU_UNRESOLVED_FILE, as adding them would fail.
+func resolveShortcodeLogins(teams []team.Team) []team.Team {
+	shortcode := viper.GetString("EMU_SHORTCODE")
+	if shortcode == "" {
+		return teams
+	}
+
+	logins, err := api.GetTargetOrganizationMembers()
+	if err != nil {
+		log.Fatalf("Unable to read members of target organization - %v", err)
+	}
+	members := make(map[string]bool)
+	for _, login := range logins {
+		members[strings.ToLower(login)] = true
+	}
+
+	users := mapping.Get(mapping.Users)
+	unresolved := make(map[string]string)
+	derived := 0
+	resolved := func(login string) bool {
+		if _, exists := users.Target(login); exists {
+			return true
+		}
+		target := mapping.ShortcodeLogin(login, shortcode, viper.GetString("SOURCE_EMU_SHORTCODE"))
+		if !members[strings.ToLower(target)] {
+			unresolved[login] = target
+			return false
+		}
+		derived++
+		return true
+	}
+
+	for i := range teams {
+		kept := make([]team.Member, 0, len(teams[i].Members))
+		for _, member := range teams[i].Members {
+			if member.Resolved || resolved(member.Login) {
+				kept = append(kept, member)
+			} else {
+				teams[i].UnresolvedMembers = append(teams[i].UnresolvedMembers, member.Login)
+			}
+		}
+		teams[i].Members = kept
+
+		// Excluded reviewers must exist in the target organization too, unless identity resolution
+		// already found their target users
+		if teams[i].ReviewAssignment != nil && !teams[i].ReviewAssignment.Resolved && len(teams[i].ReviewAssignment.ExcludedMembers) > 0 {
+			assignment := *teams[i].ReviewAssignment
+			assignment.ExcludedMembers = make([]string, 0, len(teams[i].ReviewAssignment.ExcludedMembers))
+			for _, login := range teams[i].ReviewAssignment.ExcludedMembers {
+				if resolved(login) {
+					assignment.ExcludedMembers = append(assignment.ExcludedMembers, login)
+				}
+			}
+			teams[i].ReviewAssignment = &assignment
+		}
+	}
+
+	log.Println("Derived " + strconv.Itoa(derived) + " member logins with shortcode " + shortcode)
+	if len(unresolved) > 0 {
+		filename := viper.GetString("EMU_UNRESOLVED_FILE")
+		if err := mapping.WriteUnresolved(filename, unresolved); err != nil {
+			log.Println("Unable to write EMU unresolved file - ", err)
+		}
+		log.Println(strconv.Itoa(len(unresolved)) + " members have no user in the target organization and are not added to their teams, nor are members of their teams pruned, review them in " + filename)
+	}
+
+	return teams
+}
@@ -1,97 +1,221 @@
 package sync
 
 import (
-	"encoding/csv"
 	"log"
 	"os"
 	"strconv"
 	"strings"
+	"sync/atomic"
 	"time"
 
+	"github.com/mona-actions/gh-migrate-teams/internal/mapping"
 	"github.com/mona-actions/gh-migrate-teams/internal/repository"
 	"github.com/mona-actions/gh-migrate-teams/internal/team"
 	"github.com/pterm/pterm"
+	"github.com/spf13/viper"
 )
 
 func SyncTeams() {
-	// Get all teams from source organization
-	teamsSpinnerSuccess, _ := pterm.DefaultSpinner.Start("Fetching teams from organization...")
-	teams := team.GetSourceOrganizationTeams()
-	teamsSpinnerSuccess.Success()
+	filter := loadTeamFilter()
+	openCheckpoint(runDescription("sync "+viper.GetString("SOURCE_ORGANIZATION")+" to "+viper.GetString("TARGET_ORGANIZATION"), filter))
+	runId := startRun()
+	loadMappings()
 
-	// Create teams in target organization
-	createTeamsSpinnerSuccess, _ := pterm.DefaultSpinner.Start("Creating teams in target organization...")
-	for _, team := range teams {
-		// Map members
-		if os.Getenv("GHMT_MAPPING_FILE") != "" {
-			team = mapMembers(team)
+	// Get all teams from source organization, unless an interrupted run already fetched them
+	var teams []team.Team
+	if !loadCheckpoint("teams", &teams) {
+		teamsSpinnerSuccess, _ := pterm.DefaultSpinner.Start("Fetching teams from organization...")
+		teams = team.GetSourceOrganizationTeams(filter)
+		saveCheckpoint("teams", teams)
+		teamsSpinnerSuccess.Success()
+	}
+
+	teams = resolveIdentities(teams, true)
+	teams = resolveShortcodeLogins(teams)
+
+	// Map members
+	if os.Getenv("GHMT_MAPPING_FILE") != "" || viper.GetString("EMU_SHORTCODE") != "" {
+		for i := range teams {
+			teams[i] = mapMembers(teams[i])
 		}
-		team.CreateTeam()
 	}
-	createTeamsSpinnerSuccess.Success()
+
+	teams = linkExternalGroups(teams)
+	teams = mapIdPGroups(teams)
+	teams = renameTeams(teams)
+
+	syncTeams(teams, true)
+	closeCheckpoint()
+	finishRun(runId)
 }
 
-func mapMembers(team team.Team) team.Team {
-	for i, member := range team.Members {
-		// Check if member handle is in mapping file
-		target_handle, err := getTargetHandle(os.Getenv("GHMT_MAPPING_FILE"), member.Login)
-		if err != nil {
-			log.Println("Unable to read or open mapping file")
-		}
-		team.Members[i] = updateMemberHandle(member, member.Login, target_handle)
+// syncTeams creates or reconciles the teams in the target organization, parents first.
+// pruneTeams allows target teams that are not part of the teams to be deleted.
+func syncTeams(teams []team.Team, pruneTeams bool) {
+	// The secret team policy can stop the run, so it is applied before any write to the target
+	teams = applySecretPolicy(teams)
+	ensureCustomRoles(team.Teams(teams).CustomRoles())
+
+	// Create parent teams before their children
+	teams = orderTeams(teams)
+
+	if viper.GetBool("RECONCILE") || viper.GetBool("PRUNE") {
+		reconcileSpinnerSuccess, _ := pterm.DefaultSpinner.Start("Reconciling teams in target organization...")
+		reconcileTeams(teams, pruneTeams, nil)
+		reconcileSpinnerSuccess.Success()
+	} else {
+		// Create teams in target organization
+		createTeamsSpinnerSuccess, _ := pterm.DefaultSpinner.Start("Creating teams in target organization...")
+		forEachTeam(pendingTeams(teams), func(t team.Team) {
+			// Teams with failed writes are left for a resumed run to retry
+			if t.CreateTeam() {
+				markTeamDone(t)
+			} else {
+				markUnfinished()
+			}
+		})
+		createTeamsSpinnerSuccess.Success()
 	}
-	return team
+
+	reparentTeams(teams)
 }
 
-func updateMemberHandle(member team.Member, source_handle string, target_handle string) team.Member {
-	// Update member handles
-	if member.Login == source_handle {
-		member.Login = target_handle
+// orderTeams sorts teams so that parent teams are created before their children
+func orderTeams(teams []team.Team) []team.Team {
+	sorted, missing, err := team.Teams(teams).SortByHierarchy()
+	if err != nil {
+		log.Fatalf("Unable to order teams - %v", err)
 	}
-	return member
+
+	for _, parent := range missing {
+		log.Println("Parent team", parent, "is not part of this sync, it must already exist in the target organization")
+	}
+
+	return sorted
+}
+
+// reparentTeams sets the parent of any team that was created without one, which
+// happens when its parent could not be found in the target organization at the time
+func reparentTeams(teams []team.Team) {
+	reparentSpinnerSuccess, _ := pterm.DefaultSpinner.Start("Setting parent teams in target organization...")
+	var failed atomic.Int64
+	runConcurrently(concurrency(), len(teams), func(i int) {
+		if err := teams[i].EnsureParent(); err != nil {
+			log.Println("Unable to set parent team", teams[i].ParentTeamName, "for team", teams[i].Slug, "-", err)
+			failed.Add(1)
+		}
+	})
+
+	if failed.Load() > 0 {
+		reparentSpinnerSuccess.Warning("Unable to set the parent of " + strconv.FormatInt(failed.Load(), 10) + " teams")
+		return
+	}
+	reparentSpinnerSuccess.Success()
 }
 
-func getTargetHandle(filename string, source_handle string) (string, error) {
-	// Open mapping file
-	file, err := os.Open(filename)
+// loadTeamFilter returns the team selection filter set by the team selection flags
+func loadTeamFilter() *team.Filter {
+	filter, err := team.LoadFilter()
 	if err != nil {
-		return "", err
+		log.Fatalf("Unable to select teams - %v", err)
+	}
+	// Every target team outside the selected teams would be deleted
+	if viper.GetBool("PRUNE") && viper.GetBool("PRUNE_TEAMS") && !filter.IsEmpty() {
+		log.Fatalf("Unable to select teams - --prune-teams cannot be combined with team selection flags (%s)", filter)
 	}
-	defer file.Close()
+	return filter
+}
 
-	// Parse mapping file
-	reader := csv.NewReader(file)
-	reader.FieldsPerRecord = -1 // Allow variable number of fields per record
-	records, err := reader.ReadAll()
+// mapTeamNames applies the team mapping file to teams of several source organizations, owners
+// maps the ID of each team to its organization
+func mapTeamNames(teams []team.Team, owners map[string]string) []team.Team {
+	byOwner := make(map[string][]int)
+	for i, t := range teams {
+		byOwner[owners[t.Id]] = append(byOwner[owners[t.Id]], i)
+	}
+	for owner, indexes := range byOwner {
+		ownerTeams := make(team.Teams, 0, len(indexes))
+		for _, i := range indexes {
+			ownerTeams = append(ownerTeams, teams[i])
+		}
+		for j, t := range ownerTeams.MapNames(owner) {
+			teams[indexes[j]] = t
+		}
+	}
+	return teams
+}
+
+// renameTeams applies the rename rules file to the teams and their repositories
+func renameTeams(teams []team.Team) []team.Team {
+	rules := loadRenameRules()
+	if rules == nil {
+		return teams
+	}
+
+	return team.Teams(teams).Rename(rules)
+}
+
+// loadRenameRules reads the rename rules file, no rules are returned when it is not set
+func loadRenameRules() team.RenameRules {
+	filename := viper.GetString("RENAME_RULES_FILE")
+	if filename == "" {
+		return nil
+	}
+
+	rules, err := team.LoadRenameRules(filename)
 	if err != nil {
-		return "", err
+		log.Fatalf("Unable to read rename rules - %v", err)
 	}
+	return rules
+}
 
-	// Find target value for source value
-	for _, record := range records[1:] {
-		if record[0] == source_handle {
-			//if filename contains the string gei, return the third column
-			if strings.Contains(filename, "gei") {
-				return record[2], nil
-			}
-			return record[1], nil
+func mapMembers(team team.Team) team.Team {
+	for i, member := range team.Members {
+		// Members resolved by identity already have their target login
+		if member.Resolved {
+			continue
+		}
+		// Check if member handle is in mapping file
+		team.Members[i] = updateMemberHandle(member, member.Login, targetLogin(member.Login))
+	}
+
+	// Excluded reviewers are members too, so they are mapped the same way unless identity
+	// resolution already did
+	if team.ReviewAssignment != nil && !team.ReviewAssignment.Resolved && len(team.ReviewAssignment.ExcludedMembers) > 0 {
+		assignment := *team.ReviewAssignment
+		assignment.ExcludedMembers = make([]string, len(team.ReviewAssignment.ExcludedMembers))
+		for i, login := range team.ReviewAssignment.ExcludedMembers {
+			assignment.ExcludedMembers[i] = targetLogin(login)
 		}
+		team.ReviewAssignment = &assignment
 	}
+	return team
+}
+
+// targetLogin returns the login of a source user in the target organization: its mapping, else
+// the login derived from EMU_SHORTCODE, else the source login
+func targetLogin(login string) string {
+	if target, exists := mapping.Get(mapping.Users).Target(login); exists {
+		return target
+	}
+	if shortcode := viper.GetString("EMU_SHORTCODE"); shortcode != "" {
+		return mapping.ShortcodeLogin(login, shortcode, viper.GetString("SOURCE_EMU_SHORTCODE"))
+	}
+	return login
+}
 
-	return source_handle, nil
+func updateMemberHandle(member team.Member, source_handle string, target_handle string) team.Member {
+	// Update member handles
+	if member.Login == source_handle {
+		member.Login = target_handle
+	}
+	return member
 }
 
 // filterTeamRepositories filters a team's repositories to only include those in the repository list
 func filterTeamRepositories(t team.Team, repoList []string) team.Team {
 	// Create a map for faster lookup of repositories in the migration list
-	repoMap := make(map[string]bool)
-	for _, repo := range repoList {
-		// Extract just the repository name from owner/repo format
-		parts := strings.Split(repo, "/")
-		if len(parts) == 2 {
-			repoMap[parts[1]] = true
-		}
-	}
+	repoMap := targetRepositoryNames(repoList)
 
 	// Filter repositories to only include those in the migration list
 	// We'll build a new slice by iterating through existing repositories
@@ -107,6 +231,26 @@ func filterTeamRepositories(t team.Team, repoList []string) team.Team {
 	return t
 }
 
+// targetRepositoryNames returns the names of the repositories in the repository list as teams
+// refer to them, which is their new name when they are in the repository mapping file
+func targetRepositoryNames(repoList []string) map[string]bool {
+	repoMappings := mapping.Get(mapping.Repositories)
+	names := make(map[string]bool)
+	for _, repo := range repoList {
+		// Extract just the repository name from owner/repo format
+		parts := strings.Split(repo, "/")
+		if len(parts) != 2 {
+			continue
+		}
+		if newName, exists := repoMappings.Target(repo); exists {
+			names[team.TargetRepository(newName)] = true
+		} else {
+			names[parts[1]] = true
+		}
+	}
+	return names
+}
+
 func SyncTeamsByRepo() {
 
 	teamsSpinnerSuccess, _ := pterm.DefaultSpinner.WithDelay(1 * time.Minute).Start("Fetching teams from repository list...")
@@ -121,21 +265,43 @@ func SyncTeamsByRepo() {
 		return
 	}
 	log.Println("Fetched a total of " + strconv.Itoa(len(repos)) + " repositories from the repository list")
+	filter := loadTeamFilter()
+	loadMappings()
+
+	openCheckpoint(runDescription("sync byRepos "+os.Getenv("GHMT_REPO_FILE")+" to "+viper.GetString("TARGET_ORGANIZATION"), filter))
+	runId := startRun()
 
+	// The team mapping is keyed by the source organization of each team
+	owners := make(map[string]string)
 	for _, repo := range repos {
-		log.Println("Fetching teams for repository: " + repo)
-		// get all teams that have access to the repository
-		repoTeams := team.GetRepositoryTeams(repo)
+		// The owner of the repository is the source organization of its teams, also when they
+		// were fetched by an interrupted run
+		owner := strings.Split(repo, "/")[0]
+		viper.Set("SOURCE_ORGANIZATION", owner)
+
+		// get all teams that have access to the repository, unless an interrupted run already fetched them
+		var repoTeams []team.Team
+		if !loadCheckpoint("repository:"+repo, &repoTeams) {
+			log.Println("Fetching teams for repository: " + repo)
+			repoTeams = team.GetRepositoryTeams(repo)
+			saveCheckpoint("repository:"+repo, repoTeams)
+		}
 		for _, t := range repoTeams {
 			// Check if the team is already in the map
 			if _, exists := teamMap[t.Id]; !exists {
 				// If the team is not in the map, add it to the map and the teams slice
 				teamMap[t.Id] = true
+				owners[t.Id] = owner
 				teams = append(teams, t)
-				totalMembers += len(t.Members)
 			}
 		}
 	}
+	// Only keep the teams selected by the team selection flags, which match source slugs
+	teams = mapTeamNames(filter.Select(teams), owners)
+	for _, t := range teams {
+		totalMembers += len(t.Members)
+	}
+
 	// Print out how many teams were found:
 	teamsSpinnerSuccess.UpdateText("Fetched a total of " + strconv.Itoa(len(teams)) + " teams with total of " + strconv.Itoa(totalMembers) + " members from the repository list")
 
@@ -147,28 +313,67 @@ func SyncTeamsByRepo() {
 
 	teamsSpinnerSuccess.Success()
 
-	// Create teams in target organization
-	createTeamsSpinnerSuccess, _ := pterm.DefaultSpinner.WithDelay(1 * time.Minute).Start("Creating teams in target organization...")
-	for _, team := range teams {
+	// Filter repositories to only include those in the migration list (unless disabled)
+	includeAllRepos, _ := strconv.ParseBool(os.Getenv("GHMT_INCLUDE_ALL_REPOS"))
+	teams = resolveIdentities(teams, true)
+	teams = resolveShortcodeLogins(teams)
+	for i := range teams {
 		// Map members
-		if os.Getenv("GHMT_MAPPING_FILE") != "" {
-			team = mapMembers(team)
+		if os.Getenv("GHMT_MAPPING_FILE") != "" || viper.GetString("EMU_SHORTCODE") != "" {
+			teams[i] = mapMembers(teams[i])
 		}
 
-		// Filter repositories to only include those in the migration list (unless disabled)
-		includeAllRepos, _ := strconv.ParseBool(os.Getenv("GHMT_INCLUDE_ALL_REPOS"))
-
 		if !includeAllRepos {
 			// only process repositories from repo-file
-			team = filterTeamRepositories(team, repos)
+			teams[i] = filterTeamRepositories(teams[i], repos)
 		}
+	}
+
+	teams = linkExternalGroups(teams)
+	teams = mapIdPGroups(teams)
+	// Rename after filtering, which matches the source repository names
+	teams = renameTeams(teams)
 
-		//update Spinner text with the team name
-		log.Println("Creating team in target organization: " + team.Name)
+	// The secret team policy can stop the run, so it is applied before any write to the target
+	teams = applySecretPolicy(teams)
+	ensureCustomRoles(team.Teams(teams).CustomRoles())
 
-		team.CreateTeam()
+	// Create parent teams before their children
+	teams = orderTeams(teams)
+
+	if viper.GetBool("RECONCILE") || viper.GetBool("PRUNE") {
+		reconcileSpinnerSuccess, _ := pterm.DefaultSpinner.WithDelay(1 * time.Minute).Start("Reconciling teams in target organization...")
+		// Only prune access to repositories from the repo-file unless all repositories were included
+		var repoList []string
+		if !includeAllRepos {
+			repoList = repos
+		}
+		reconcileTeams(teams, false, repoList)
+		reconcileSpinnerSuccess.Success()
+	} else {
+		// Create teams in target organization
+		createTeamsSpinnerSuccess, _ := pterm.DefaultSpinner.WithDelay(1 * time.Minute).Start("Creating teams in target organization...")
+		forEachTeam(pendingTeams(teams), func(t team.Team) {
+			//update Spinner text with the team name
+			log.Println("Creating team in target organization: " + t.Name)
 
+			// Teams with failed writes are left for a resumed run to retry
+			if t.CreateTeam() {
+				markTeamDone(t)
+			} else {
+				markUnfinished()
+			}
+		})
+		createTeamsSpinnerSuccess.UpdateText("Team creation process completed")
+		createTeamsSpinnerSuccess.Success()
 	}
-	createTeamsSpinnerSuccess.UpdateText("Team creation process completed")
-	createTeamsSpinnerSuccess.Success()
+
+	reparentTeams(teams)
+
+	if viper.GetBool("SYNC_COLLABORATORS") {
+		syncCollaborators(repos)
+	}
+
+	closeCheckpoint()
+	finishRun(runId)
 }
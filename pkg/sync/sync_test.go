@@ -2,10 +2,15 @@ package sync
 
 import (
 	"fmt"
+	"os"
+	"path/filepath"
 	"reflect"
 	"testing"
 
+	"github.com/mona-actions/gh-migrate-teams/internal/mapping"
+	"github.com/mona-actions/gh-migrate-teams/internal/repository"
 	"github.com/mona-actions/gh-migrate-teams/internal/team"
+	"github.com/spf13/viper"
 )
 
 func TestFilterTeamRepositories(t *testing.T) {
@@ -232,3 +237,176 @@ func BenchmarkFilterTeamRepositories(b *testing.B) {
 		filterTeamRepositories(testTeam, repoList)
 	}
 }
+
+func TestTargetRepositoryNames(t *testing.T) {
+	filename := filepath.Join(t.TempDir(), "repos.csv")
+	if err := os.WriteFile(filename, []byte("source,target\nowner/repo1,renamed-repo1\nowner/repo3,target/repo3\nowner/repo4,other/repo4\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		viper.Reset()
+		mapping.LoadConfigured()
+	})
+	viper.Set("REPO_MAPPING_FILE", filename)
+	viper.Set("TARGET_ORGANIZATION", "target")
+	if err := mapping.LoadConfigured(); err != nil {
+		t.Fatal(err)
+	}
+
+	got := targetRepositoryNames([]string{"owner/repo1", "owner/repo2", "owner/repo3", "owner/repo4", "invalid"})
+	want := map[string]bool{"renamed-repo1": true, "repo2": true, "repo3": true, "other/repo4": true}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("targetRepositoryNames() = %v, want %v", got, want)
+	}
+}
+
+func TestMapTeamNames(t *testing.T) {
+	filename := filepath.Join(t.TempDir(), "teams.csv")
+	if err := os.WriteFile(filename, []byte("source,target\nacme/web,Frontend\nlabs/web,Labs Web\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		viper.Reset()
+		mapping.LoadConfigured()
+	})
+	viper.Set("TEAM_MAPPING_FILE", filename)
+	if err := mapping.LoadConfigured(); err != nil {
+		t.Fatal(err)
+	}
+
+	teams := []team.Team{
+		{Id: "1", Name: "Web", Slug: "web"},
+		{Id: "2", Name: "Ops", Slug: "ops"},
+		{Id: "3", Name: "Web", Slug: "web"},
+	}
+	got := mapTeamNames(teams, map[string]string{"1": "acme", "2": "acme", "3": "labs"})
+	want := []string{"frontend", "ops", "labs-web"}
+	for i, slug := range want {
+		if got[i].Slug != slug {
+			t.Errorf("team %d = %s, want %s", i, got[i].Slug, slug)
+		}
+	}
+}
+
+func TestTargetCollaboratorRepository(t *testing.T) {
+	filename := filepath.Join(t.TempDir(), "repos.csv")
+	if err := os.WriteFile(filename, []byte("source,target\nowner/repo1,renamed-repo1\nowner/repo3,target/repo3\nowner/repo4,other/repo4\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		viper.Reset()
+		mapping.LoadConfigured()
+	})
+	viper.Set("REPO_MAPPING_FILE", filename)
+	viper.Set("TARGET_ORGANIZATION", "target")
+	if err := mapping.LoadConfigured(); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := map[string]string{
+		"owner/repo1": "renamed-repo1",
+		"owner/repo2": "repo2",
+		"owner/repo3": "repo3",
+		"owner/repo4": "other/repo4",
+	}
+	for repo, want := range tests {
+		if got := targetCollaboratorRepository(repo, nil); got != want {
+			t.Errorf("targetCollaboratorRepository(%q) = %q, want %q", repo, got, want)
+		}
+	}
+}
+
+func TestCollaboratorLogins(t *testing.T) {
+	filename := filepath.Join(t.TempDir(), "users.csv")
+	if err := os.WriteFile(filename, []byte("source,target\nmona,mona-target\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		viper.Reset()
+		mapping.LoadConfigured()
+	})
+	viper.Set("MAPPING_FILE", filename)
+	viper.Set("EMU_SHORTCODE", "acme")
+	if err := mapping.LoadConfigured(); err != nil {
+		t.Fatal(err)
+	}
+
+	sources := [][]repository.Collaborator{
+		{{Login: "Mona", Permission: "push"}, {Login: "hubot", Permission: "pull", Outside: true}},
+		nil,
+	}
+	got := mapCollaborators(sources[0], collaboratorLogins(sources))
+	want := []repository.Collaborator{
+		{Login: "mona-target", Permission: "push"},
+		{Login: "hubot_acme", Permission: "pull", Outside: true},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("mapCollaborators() = %v, want %v", got, want)
+	}
+	if operation := collaboratorOperation(got[1]); operation != operationAddOutsideCollaborator {
+		t.Errorf("collaboratorOperation() = %s for an outside collaborator, want %s", operation, operationAddOutsideCollaborator)
+	}
+}
+
+func TestResolveExcludedMembers(t *testing.T) {
+	filename := filepath.Join(t.TempDir(), "users.csv")
+	if err := os.WriteFile(filename, []byte("source,target\nmona,mona-target\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		viper.Reset()
+		mapping.LoadConfigured()
+	})
+	viper.Set("MAPPING_FILE", filename)
+	viper.Set("EMU_SHORTCODE", "acme")
+	if err := mapping.LoadConfigured(); err != nil {
+		t.Fatal(err)
+	}
+
+	assignment := &team.ReviewAssignment{Enabled: true, ExcludedMembers: []string{"Alice", "mona", "bob"}}
+	got := resolveExcludedMembers(assignment, map[string]string{"alice": "alice-target"}, mappedLogins())
+	// bob is neither resolved nor mapped, so it is left out
+	if want := []string{"alice-target", "mona-target"}; !reflect.DeepEqual(got.ExcludedMembers, want) {
+		t.Errorf("resolveExcludedMembers() = %v, want %v", got.ExcludedMembers, want)
+	}
+
+	// Resolved excluded members are not mapped again
+	mapped := mapMembers(team.Team{ReviewAssignment: got})
+	if want := []string{"alice-target", "mona-target"}; !reflect.DeepEqual(mapped.ReviewAssignment.ExcludedMembers, want) {
+		t.Errorf("mapMembers() = %v, want %v", mapped.ReviewAssignment.ExcludedMembers, want)
+	}
+}
+
+func TestReportFiles(t *testing.T) {
+	tests := map[string][2]string{
+		"gh-migrate-teams-report.json": {"gh-migrate-teams-report.json", "gh-migrate-teams-report"},
+		"out/report.csv":               {"out/report.json", "out/report"},
+		"report.CSV":                   {"report.json", "report"},
+		"report":                       {"report", "report"},
+	}
+	for filename, want := range tests {
+		if jsonFile, base := reportFiles(filename); jsonFile != want[0] || base != want[1] {
+			t.Errorf("reportFiles(%q) = %q, %q, want %q, %q", filename, jsonFile, base, want[0], want[1])
+		}
+	}
+}
+
+func TestCloseCheckpointKeepsUnfinishedRuns(t *testing.T) {
+	t.Cleanup(viper.Reset)
+	filename := filepath.Join(t.TempDir(), "state.jsonl")
+	viper.Set("STATE_FILE", filename)
+
+	openCheckpoint("sync source to target")
+	markUnfinished()
+	closeCheckpoint()
+	if _, err := os.Stat(filename); err != nil {
+		t.Fatalf("state file of an unfinished run was removed - %v", err)
+	}
+
+	viper.Set("RESUME", true)
+	openCheckpoint("sync source to target")
+	closeCheckpoint()
+	if _, err := os.Stat(filename); !os.IsNotExist(err) {
+		t.Errorf("state file of a finished run was kept - %v", err)
+	}
+}
@@ -0,0 +1,73 @@
+package sync
+
+import (
+	"strings"
+	gosync "sync"
+
+	"github.com/mona-actions/gh-migrate-teams/internal/team"
+	"github.com/spf13/viper"
+)
+
+// concurrency returns the number of teams to process at the same time
+func concurrency() int {
+	workers := viper.GetInt("CONCURRENCY")
+	if workers < 1 {
+		return 1
+	}
+	return workers
+}
+
+// runConcurrently calls fn for every index in [0, jobs) using up to workers goroutines
+func runConcurrently(workers int, jobs int, fn func(i int)) {
+	queue := make(chan int)
+	var wg gosync.WaitGroup
+
+	for w := 0; w < workers && w < jobs; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range queue {
+				fn(i)
+			}
+		}()
+	}
+
+	for i := 0; i < jobs; i++ {
+		queue <- i
+	}
+	close(queue)
+	wg.Wait()
+}
+
+// hierarchyLevels groups teams by their depth in the team hierarchy. Teams must already
+// be sorted so that parents come before their children.
+func hierarchyLevels(teams []team.Team) [][]team.Team {
+	depths := make(map[string]int)
+	levels := make([][]team.Team, 0)
+
+	for _, t := range teams {
+		depth := 0
+		if parentDepth, exists := depths[strings.ToLower(t.ParentTeamName)]; exists && t.ParentTeamName != "" {
+			depth = parentDepth + 1
+		}
+		depths[strings.ToLower(t.Slug)] = depth
+
+		for len(levels) <= depth {
+			levels = append(levels, make([]team.Team, 0))
+		}
+		levels[depth] = append(levels[depth], t)
+	}
+
+	return levels
+}
+
+// forEachTeam calls fn for every team using the configured number of workers. Teams are
+// processed one hierarchy level at a time so that parents are finished before their children.
+func forEachTeam(teams []team.Team, fn func(t team.Team)) {
+	workers := concurrency()
+	for _, level := range hierarchyLevels(teams) {
+		runConcurrently(workers, len(level), func(i int) {
+			fn(level[i])
+		})
+	}
+}
@@ -0,0 +1,59 @@
+package sync
+
+import (
+	"reflect"
+	gosync "sync"
+	"testing"
+
+	"github.com/mona-actions/gh-migrate-teams/internal/team"
+)
+
+func TestHierarchyLevels(t *testing.T) {
+	teams := []team.Team{
+		{Slug: "root"},
+		{Slug: "other"},
+		{Slug: "child", ParentTeamName: "Root"},
+		{Slug: "grandchild", ParentTeamName: "child"},
+		{Slug: "orphan", ParentTeamName: "not-synced"},
+	}
+
+	levels := hierarchyLevels(teams)
+
+	expected := [][]string{
+		{"root", "other", "orphan"},
+		{"child"},
+		{"grandchild"},
+	}
+	result := make([][]string, 0, len(levels))
+	for _, level := range levels {
+		slugs := make([]string, 0, len(level))
+		for _, t := range level {
+			slugs = append(slugs, t.Slug)
+		}
+		result = append(result, slugs)
+	}
+
+	if !reflect.DeepEqual(result, expected) {
+		t.Errorf("hierarchyLevels() = %v, expected %v", result, expected)
+	}
+}
+
+func TestRunConcurrently(t *testing.T) {
+	var mu gosync.Mutex
+	seen := make(map[int]int)
+
+	runConcurrently(4, 25, func(i int) {
+		mu.Lock()
+		seen[i]++
+		mu.Unlock()
+	})
+
+	if len(seen) != 25 {
+		t.Fatalf("runConcurrently() ran %d jobs, expected 25", len(seen))
+	}
+	for i, count := range seen {
+		if count != 1 {
+			t.Errorf("runConcurrently() ran job %d %d times, expected once", i, count)
+		}
+	}
+}